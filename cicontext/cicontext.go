@@ -0,0 +1,173 @@
+package cicontext
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// supported ci providers
+const (
+	ProviderGithub    = "github"
+	ProviderGitlab    = "gitlab"
+	ProviderGitea     = "gitea"
+	ProviderBuildkite = "buildkite"
+	ProviderJenkins   = "jenkins"
+)
+
+// Context is what the ci environment tells us about the current build. Fields are empty when unknown.
+type Context struct {
+	Provider string
+	// Repo is owner/repo (group/subgroup/project on gitlab)
+	Repo string
+	// PR is the pull/merge request number
+	PR     int
+	SHA    string
+	Branch string
+	// RunURL links to the build
+	RunURL string
+}
+
+// Detect reads the current ci context from the environment
+func Detect() *Context {
+	switch {
+	case os.Getenv("GITEA_ACTIONS") == "true":
+		// gitea actions sets the same variables as github actions
+		c := detectGithub()
+		c.Provider = ProviderGitea
+		return c
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return detectGithub()
+	case os.Getenv("GITLAB_CI") == "true":
+		return detectGitlab()
+	case os.Getenv("BUILDKITE") == "true":
+		return detectBuildkite()
+	case os.Getenv("JENKINS_URL") != "":
+		return detectJenkins()
+	}
+	return &Context{}
+}
+
+// githubEvent is the subset of the pull_request, issue_comment and push payloads we care about
+type githubEvent struct {
+	PullRequest *struct {
+		Number int `json:"number"`
+		Head   struct {
+			SHA string `json:"sha"`
+			Ref string `json:"ref"`
+		} `json:"head"`
+	} `json:"pull_request"`
+	Issue *struct {
+		Number      int              `json:"number"`
+		PullRequest *json.RawMessage `json:"pull_request"`
+	} `json:"issue"`
+	After string `json:"after"`
+}
+
+func detectGithub() *Context {
+	c := &Context{
+		Provider: ProviderGithub,
+		Repo:     os.Getenv("GITHUB_REPOSITORY"),
+		SHA:      os.Getenv("GITHUB_SHA"),
+		Branch:   firstNonEmpty(os.Getenv("GITHUB_HEAD_REF"), os.Getenv("GITHUB_REF_NAME")),
+	}
+	if serverURL, runID := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_RUN_ID"); serverURL != "" && runID != "" {
+		c.RunURL = fmt.Sprintf("%s/%s/actions/runs/%s", serverURL, c.Repo, runID)
+	}
+
+	eventPath := os.Getenv("GITHUB_EVENT_PATH")
+	if eventPath == "" {
+		return c
+	}
+	eventContents, err := os.ReadFile(eventPath)
+	if err != nil {
+		return c
+	}
+	event := &githubEvent{}
+	if err := json.Unmarshal(eventContents, event); err != nil {
+		return c
+	}
+	switch {
+	case event.PullRequest != nil:
+		// GITHUB_SHA is the merge commit for pull_request events, use the head instead
+		c.PR = event.PullRequest.Number
+		c.SHA = firstNonEmpty(event.PullRequest.Head.SHA, c.SHA)
+		c.Branch = firstNonEmpty(event.PullRequest.Head.Ref, c.Branch)
+	case event.Issue != nil && event.Issue.PullRequest != nil:
+		// issue_comment on a PR. GITHUB_SHA is the default branch here so there is no useful sha
+		c.PR = event.Issue.Number
+		c.SHA = ""
+		c.Branch = ""
+	case event.After != "":
+		c.SHA = event.After
+	}
+	return c
+}
+
+func detectGitlab() *Context {
+	c := &Context{
+		Provider: ProviderGitlab,
+		Repo:     os.Getenv("CI_PROJECT_PATH"),
+		SHA:      os.Getenv("CI_COMMIT_SHA"),
+		Branch:   firstNonEmpty(os.Getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME"), os.Getenv("CI_COMMIT_REF_NAME")),
+		RunURL:   firstNonEmpty(os.Getenv("CI_JOB_URL"), os.Getenv("CI_PIPELINE_URL")),
+	}
+	c.PR, _ = strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID"))
+	return c
+}
+
+func detectBuildkite() *Context {
+	c := &Context{
+		Provider: ProviderBuildkite,
+		Repo:     RepoFromGitURL(os.Getenv("BUILDKITE_REPO")),
+		SHA:      os.Getenv("BUILDKITE_COMMIT"),
+		Branch:   os.Getenv("BUILDKITE_BRANCH"),
+		RunURL:   os.Getenv("BUILDKITE_BUILD_URL"),
+	}
+	// "false" when the build isn't for a pull request
+	c.PR, _ = strconv.Atoi(os.Getenv("BUILDKITE_PULL_REQUEST"))
+	// HEAD is used for builds triggered without a specific commit
+	if c.SHA == "HEAD" {
+		c.SHA = ""
+	}
+	return c
+}
+
+func detectJenkins() *Context {
+	c := &Context{
+		Provider: ProviderJenkins,
+		Repo:     RepoFromGitURL(os.Getenv("GIT_URL")),
+		SHA:      os.Getenv("GIT_COMMIT"),
+		Branch:   firstNonEmpty(os.Getenv("CHANGE_BRANCH"), os.Getenv("BRANCH_NAME"), os.Getenv("GIT_BRANCH")),
+		RunURL:   os.Getenv("BUILD_URL"),
+	}
+	// CHANGE_ID is set by multibranch pipelines building a pull request
+	c.PR, _ = strconv.Atoi(os.Getenv("CHANGE_ID"))
+	return c
+}
+
+var gitURLRe = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?[^/:]+(?::\d+)?[:/](.+?)(?:\.git)?/?$`)
+
+// RepoFromGitURL returns owner/repo from a git remote url
+//
+// git@github.com:owner/repo.git => owner/repo
+// https://github.com/owner/repo.git => owner/repo
+func RepoFromGitURL(gitURL string) string {
+	matches := gitURLRe.FindStringSubmatch(strings.TrimSpace(gitURL))
+	if matches == nil {
+		return ""
+	}
+	return strings.TrimPrefix(matches[1], "/")
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, val := range vals {
+		if val != "" {
+			return val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package cicontext
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// clearProviders makes sure the ci running the tests doesn't leak into them
+func clearProviders(t *testing.T) {
+	for _, k := range []string{"GITEA_ACTIONS", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "JENKINS_URL", "GITHUB_EVENT_PATH", "GITHUB_HEAD_REF"} {
+		t.Setenv(k, "")
+	}
+}
+
+func TestDetectGithubPullRequest(t *testing.T) {
+	clearProviders(t)
+	eventPath := filepath.Join(t.TempDir(), "event.json")
+	err := os.WriteFile(eventPath, []byte(`{"pull_request": {"number": 12, "head": {"sha": "headsha", "ref": "my-branch"}}}`), 0o600)
+	require.NoError(t, err)
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	t.Setenv("GITHUB_SHA", "mergesha")
+	t.Setenv("GITHUB_EVENT_PATH", eventPath)
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_RUN_ID", "99")
+
+	require.Equal(t, &Context{
+		Provider: ProviderGithub,
+		Repo:     "owner/repo",
+		PR:       12,
+		SHA:      "headsha",
+		Branch:   "my-branch",
+		RunURL:   "https://github.com/owner/repo/actions/runs/99",
+	}, Detect())
+}
+
+func TestDetectGithubIssueComment(t *testing.T) {
+	clearProviders(t)
+	eventPath := filepath.Join(t.TempDir(), "event.json")
+	err := os.WriteFile(eventPath, []byte(`{"issue": {"number": 7, "pull_request": {"url": "x"}}}`), 0o600)
+	require.NoError(t, err)
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	t.Setenv("GITHUB_SHA", "mainsha")
+	t.Setenv("GITHUB_EVENT_PATH", eventPath)
+
+	c := Detect()
+	require.Equal(t, 7, c.PR)
+	require.Equal(t, "", c.SHA)
+}
+
+func TestDetectGitlab(t *testing.T) {
+	clearProviders(t)
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("CI_PROJECT_PATH", "group/sub/project")
+	t.Setenv("CI_COMMIT_SHA", "abc")
+	t.Setenv("CI_MERGE_REQUEST_IID", "5")
+
+	c := Detect()
+	require.Equal(t, ProviderGitlab, c.Provider)
+	require.Equal(t, "group/sub/project", c.Repo)
+	require.Equal(t, 5, c.PR)
+	require.Equal(t, "abc", c.SHA)
+}
+
+func TestDetectGitea(t *testing.T) {
+	clearProviders(t)
+	t.Setenv("GITEA_ACTIONS", "true")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	t.Setenv("GITHUB_SHA", "abc")
+
+	c := Detect()
+	require.Equal(t, ProviderGitea, c.Provider)
+	require.Equal(t, "owner/repo", c.Repo)
+	require.Equal(t, "abc", c.SHA)
+}
+
+func TestDetectBuildkite(t *testing.T) {
+	clearProviders(t)
+	t.Setenv("BUILDKITE", "true")
+	t.Setenv("BUILDKITE_REPO", "git@github.com:owner/repo.git")
+	t.Setenv("BUILDKITE_COMMIT", "abc")
+	t.Setenv("BUILDKITE_PULL_REQUEST", "false")
+
+	c := Detect()
+	require.Equal(t, "owner/repo", c.Repo)
+	require.Equal(t, 0, c.PR)
+	require.Equal(t, "abc", c.SHA)
+}
+
+func TestDetectJenkins(t *testing.T) {
+	clearProviders(t)
+	t.Setenv("JENKINS_URL", "https://jenkins.example.com")
+	t.Setenv("GIT_URL", "https://github.com/owner/repo.git")
+	t.Setenv("GIT_COMMIT", "abc")
+	t.Setenv("CHANGE_ID", "3")
+
+	c := Detect()
+	require.Equal(t, ProviderJenkins, c.Provider)
+	require.Equal(t, "owner/repo", c.Repo)
+	require.Equal(t, 3, c.PR)
+}
+
+func TestDetectNone(t *testing.T) {
+	clearProviders(t)
+	require.Equal(t, &Context{}, Detect())
+}
+
+func TestRepoFromGitURL(t *testing.T) {
+	for url, repo := range map[string]string{
+		"git@github.com:owner/repo.git":            "owner/repo",
+		"https://github.com/owner/repo.git":        "owner/repo",
+		"https://github.com/owner/repo":            "owner/repo",
+		"ssh://git@github.com:22/owner/repo.git":   "owner/repo",
+		"https://gitlab.example.com/group/sub/prj": "group/sub/prj",
+		"": "",
+	} {
+		require.Equal(t, repo, RepoFromGitURL(url), url)
+	}
+}
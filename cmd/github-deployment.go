@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alexgartner-bc/ci-multitool/github"
+	"github.com/spf13/cobra"
+)
+
+var githubDeploymentArgs = struct {
+	id             int64
+	environment    string
+	ref            string
+	description    string
+	transient      bool
+	production     bool
+	state          string
+	logURL         string
+	environmentURL string
+}{}
+
+func init() {
+	githubCmd.AddCommand(githubDeploymentCmd)
+	githubDeploymentCmd.AddCommand(githubDeploymentCreateCmd)
+	githubDeploymentCmd.AddCommand(githubDeploymentStatusCmd)
+
+	createF := githubDeploymentCreateCmd.Flags()
+	setGithubDefaultArgs(createF)
+	createF.StringVar(
+		&githubDeploymentArgs.environment,
+		"environment", "",
+		"environment to deploy to (production, staging...)",
+	)
+	createF.StringVar(
+		&githubDeploymentArgs.ref,
+		"ref", "",
+		"sha, branch or tag being deployed (default --sha)",
+	)
+	createF.StringVar(
+		&githubDeploymentArgs.description,
+		"description", "",
+		"short description of the deployment",
+	)
+	createF.BoolVar(
+		&githubDeploymentArgs.transient,
+		"transient", false,
+		"the environment will be destroyed (preview environments)",
+	)
+	createF.BoolVar(
+		&githubDeploymentArgs.production,
+		"production", false,
+		"the environment is used by end users",
+	)
+	createF.StringVar(
+		&githubDeploymentArgs.state,
+		"state", "",
+		"also set this status on the new deployment (in_progress...)",
+	)
+	setGithubDeploymentStatusArgs(githubDeploymentCreateCmd)
+
+	statusF := githubDeploymentStatusCmd.Flags()
+	setGithubDefaultArgs(statusF)
+	statusF.Int64Var(
+		&githubDeploymentArgs.id,
+		"id", 0,
+		"id of the deployment (printed by deployment create)",
+	)
+	statusF.StringVar(
+		&githubDeploymentArgs.state,
+		"state", "",
+		"state of the deployment (queued, pending, in_progress, success, failure, error, inactive)",
+	)
+	statusF.StringVar(
+		&githubDeploymentArgs.description,
+		"description", "",
+		"short description of the status",
+	)
+	setGithubDeploymentStatusArgs(githubDeploymentStatusCmd)
+}
+
+func setGithubDeploymentStatusArgs(cmd *cobra.Command) {
+	cmd.Flags().StringVar(
+		&githubDeploymentArgs.logURL,
+		"log-url", ciContext.RunURL,
+		"url of the deployment logs. detected from ci if not set",
+	)
+	cmd.Flags().StringVar(
+		&githubDeploymentArgs.environmentURL,
+		"environment-url", "",
+		"url of the deployed environment",
+	)
+}
+
+var githubDeploymentCmd = &cobra.Command{
+	Use:   "deployment",
+	Short: "create and update github deployments",
+}
+
+var githubDeploymentCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "create a deployment and print its id",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		repo := githubDefaultArgs.repo
+		if repo == "" {
+			return errors.New("repo must be set")
+		}
+		ref := githubDeploymentArgs.ref
+		if ref == "" {
+			ref = githubDefaultArgs.sha
+		}
+		if ref == "" {
+			return errors.New("either --ref or --sha must be set")
+		}
+		if githubDeploymentArgs.environment == "" {
+			return errors.New("environment must be set")
+		}
+
+		id, err := github.CreateDeployment(ctx, repo, &github.Deployment{
+			Ref:         ref,
+			Environment: githubDeploymentArgs.environment,
+			Description: githubDeploymentArgs.description,
+			Transient:   githubDeploymentArgs.transient,
+			Production:  githubDeploymentArgs.production,
+		})
+		if err != nil {
+			return err
+		}
+		if githubDeploymentArgs.state != "" {
+			err = github.SetDeploymentStatus(ctx, repo, id,
+				githubDeploymentArgs.state,
+				githubDeploymentArgs.description,
+				githubDeploymentArgs.logURL,
+				githubDeploymentArgs.environmentURL,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Println(id)
+		return nil
+	},
+}
+
+var githubDeploymentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "set the status of a deployment",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo := githubDefaultArgs.repo
+		if repo == "" {
+			return errors.New("repo must be set")
+		}
+		if githubDeploymentArgs.id == 0 {
+			return errors.New("id must be set")
+		}
+		if githubDeploymentArgs.state == "" {
+			return errors.New("state must be set")
+		}
+		return github.SetDeploymentStatus(cmd.Context(), repo,
+			githubDeploymentArgs.id,
+			githubDeploymentArgs.state,
+			githubDeploymentArgs.description,
+			githubDeploymentArgs.logURL,
+			githubDeploymentArgs.environmentURL,
+		)
+	},
+}
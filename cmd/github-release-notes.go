@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alexgartner-bc/ci-multitool/github"
+	"github.com/spf13/cobra"
+)
+
+var githubReleaseNotesArgs = struct {
+	from         string
+	to           string
+	base         string
+	groupLabels  []string
+	jiraURL      string
+	jiraProjects []string
+	releaseTag   string
+	releaseName  string
+}{}
+
+func init() {
+	githubCmd.AddCommand(githubReleaseNotesCmd)
+	fs := githubReleaseNotesCmd.Flags()
+	setGithubDefaultArgs(fs)
+	fs.StringVar(
+		&githubReleaseNotesArgs.from,
+		"from", "",
+		"ref of the previous release (v1.2.0)",
+	)
+	fs.StringVar(
+		&githubReleaseNotesArgs.to,
+		"to", "",
+		"ref being released (default --sha)",
+	)
+	fs.StringVar(
+		&githubReleaseNotesArgs.base,
+		"base", "",
+		"only list PRs merged into this branch (default the repo's default branch)",
+	)
+	fs.StringArrayVar(
+		&githubReleaseNotesArgs.groupLabels,
+		"group-label", nil,
+		"label=section. put PRs with the label in their own section, checked in order before conventional commit types. can be repeated",
+	)
+	fs.StringVar(
+		&githubReleaseNotesArgs.jiraURL,
+		"jira-url", os.Getenv("JIRA_INSTANCE_URL"),
+		"link jira keys found in PRs to this jira instance",
+	)
+	fs.StringSliceVar(
+		&githubReleaseNotesArgs.jiraProjects,
+		"jira-projects", jiraProjectsFromEnv(),
+		"only keep jira keys of these projects (comma separated). default JIRA_PROJECT",
+	)
+	fs.StringVar(
+		&githubReleaseNotesArgs.releaseTag,
+		"release-tag", "",
+		"create or update a draft release for this tag instead of printing the notes",
+	)
+	fs.StringVar(
+		&githubReleaseNotesArgs.releaseName,
+		"release-name", "",
+		"name of the draft release (default --release-tag)",
+	)
+}
+
+var githubReleaseNotesCmd = &cobra.Command{
+	Use:   "release-notes",
+	Short: "markdown release notes from the PRs merged between two refs",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		repo := githubDefaultArgs.repo
+		if repo == "" {
+			return errors.New("repo must be set")
+		}
+		from := githubReleaseNotesArgs.from
+		if from == "" {
+			return errors.New("from must be set")
+		}
+		to := githubReleaseNotesArgs.to
+		if to == "" {
+			to = githubDefaultArgs.sha
+		}
+		if to == "" {
+			return errors.New("either --to or --sha must be set")
+		}
+
+		opts := &github.ReleaseNotesOptions{
+			JiraURL:      githubReleaseNotesArgs.jiraURL,
+			JiraProjects: githubReleaseNotesArgs.jiraProjects,
+		}
+		for _, groupLabel := range githubReleaseNotesArgs.groupLabels {
+			parts := strings.SplitN(groupLabel, "=", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return fmt.Errorf("invalid --group-label %q (label=section)", groupLabel)
+			}
+			opts.LabelGroups = append(opts.LabelGroups, github.ReleaseNotesLabelGroup{Label: parts[0], Section: parts[1]})
+		}
+
+		entries, err := github.ListReleaseNoteEntries(ctx, repo, from, to, githubReleaseNotesArgs.base)
+		if err != nil {
+			return err
+		}
+		notes := github.ReleaseNotesMarkdown(entries, opts)
+
+		if githubReleaseNotesArgs.releaseTag == "" {
+			fmt.Print(notes)
+			return nil
+		}
+		url, err := github.UpsertDraftRelease(ctx, repo, githubReleaseNotesArgs.releaseTag, to, githubReleaseNotesArgs.releaseName, notes)
+		if err != nil {
+			return err
+		}
+		fmt.Println(url)
+		return nil
+	},
+}
+
+// jiraProjectsFromEnv returns the project the jira commands use, if set
+func jiraProjectsFromEnv() []string {
+	if project := os.Getenv("JIRA_PROJECT"); project != "" {
+		return []string{project}
+	}
+	return nil
+}
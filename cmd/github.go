@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 
+	"github.com/alexgartner-bc/ci-multitool/findings"
 	"github.com/alexgartner-bc/ci-multitool/github"
+	"github.com/alexgartner-bc/ci-multitool/render"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -20,23 +23,62 @@ var githubDefaultArgs = struct {
 
 var githubPRTrailerArgs = struct {
 	summary string
+	remove  bool
+	list    bool
+}{}
+
+var githubCommentArgs = struct {
+	delete           bool
+	minimize         bool
+	minimizePrevious bool
+	split            bool
+	history          bool
+	historyCount     int
+	historySize      int
+}{}
+
+var githubStatusArgs = struct {
+	state       string
+	context     string
+	description string
+	targetURL   string
+}{}
+
+var githubTemplateArgs = struct {
+	template string
+	data     string
+	env      []string
+}{}
+
+var githubLabelsArgs = struct {
+	add    []string
+	remove []string
+}{}
+
+var githubReviewArgs = struct {
+	summary string
+}{}
+
+var githubReportStatusArgs = struct {
+	context   string
+	targetURL string
 }{}
 
 func setGithubDefaultArgs(fs *pflag.FlagSet) {
 	fs.StringVar(
 		&githubDefaultArgs.repo,
-		"repo", "",
-		"name of the repo (alexgartner-bc/my-repo)",
+		"repo", ciContext.Repo,
+		"name of the repo (alexgartner-bc/my-repo). detected from ci if not set",
 	)
 	fs.IntVar(
 		&githubDefaultArgs.pr,
-		"pr", 0,
-		"number of the pr (1234)",
+		"pr", ciContext.PR,
+		"number of the pr (1234). detected from ci if not set",
 	)
 	fs.StringVar(
 		&githubDefaultArgs.sha,
-		"sha", "",
-		"sha of the commit",
+		"sha", ciContext.SHA,
+		"sha of the commit. detected from ci if not set",
 	)
 	fs.StringVar(
 		&githubDefaultArgs.key,
@@ -45,18 +87,175 @@ func setGithubDefaultArgs(fs *pflag.FlagSet) {
 	)
 }
 
+// githubCommentPR returns the pr to comment on. an explicit --sha comments on the commit unless --pr was also
+// set, the pr detected from ci does not override it.
+func githubCommentPR(cmd *cobra.Command) int {
+	if cmd.Flags().Changed("sha") && !cmd.Flags().Changed("pr") {
+		return 0
+	}
+	return githubDefaultArgs.pr
+}
+
+// setGithubTemplateArgs adds the flags to render the body from a template instead of posting a file
+func setGithubTemplateArgs(fs *pflag.FlagSet) {
+	fs.StringVar(
+		&githubTemplateArgs.template,
+		"template", "",
+		"go text/template to render instead of reading a file. has .Data, .Env and .CI (repo, pr, sha...)",
+	)
+	fs.StringVar(
+		&githubTemplateArgs.data,
+		"data", "",
+		"json or yaml file (can be - for stdin) available to --template as .Data",
+	)
+	fs.StringArrayVar(
+		&githubTemplateArgs.env,
+		"env", []string{},
+		"environment variable available to --template as .Env.NAME (can be repeated). the rest of the environment isn't",
+	)
+}
+
+// setGithubReportStatusArgs adds the flags used by report commands to publish a commit status derived from their result
+func setGithubReportStatusArgs(fs *pflag.FlagSet) {
+	fs.StringVar(
+		&githubReportStatusArgs.context,
+		"status-context", "",
+		"publish a commit status with this context on --sha (optional)",
+	)
+	fs.StringVar(
+		&githubReportStatusArgs.targetURL,
+		"status-target-url", "",
+		"url to link from the commit status (optional)",
+	)
+}
+
+// publishGithubReportStatus sets the commit status if --status-context was given
+func publishGithubReportStatus(ctx context.Context, state string, description string) error {
+	statusContext := githubReportStatusArgs.context
+	if statusContext == "" {
+		return nil
+	}
+	repo := githubDefaultArgs.repo
+	if repo == "" {
+		return errors.New("repo must be set")
+	}
+	sha := githubDefaultArgs.sha
+	if sha == "" {
+		return errors.New("sha must be set")
+	}
+	err := github.SetCommitStatus(ctx, repo, sha, state, statusContext, description, githubReportStatusArgs.targetURL)
+	if err != nil {
+		return fmt.Errorf("unable to set github status: %w", err)
+	}
+	return nil
+}
+
 func init() {
 	githubCmd.AddCommand(githubCommentCmd)
 	setGithubDefaultArgs(githubCommentCmd.Flags())
+	githubCommentCmdF := githubCommentCmd.Flags()
+	githubCommentCmdF.BoolVar(
+		&githubCommentArgs.delete,
+		"delete", false,
+		"delete the comment tagged with --key instead of posting",
+	)
+	githubCommentCmdF.BoolVar(
+		&githubCommentArgs.minimize,
+		"minimize", false,
+		"hide the comment tagged with --key as outdated instead of posting",
+	)
+	githubCommentCmdF.BoolVar(
+		&githubCommentArgs.minimizePrevious,
+		"minimize-previous", false,
+		"hide the previous comment tagged with --key as outdated and post a new one instead of editing it",
+	)
+	githubCommentCmdF.BoolVar(
+		&githubCommentArgs.split,
+		"split", false,
+		"split a comment over github's size limit into numbered comments instead of truncating it",
+	)
+
+	githubCommentCmdF.BoolVar(
+		&githubCommentArgs.history,
+		"history", false,
+		"keep the previous content (with time and --sha) in a collapsed \"Previous runs\" section when updating the comment",
+	)
+	githubCommentCmdF.IntVar(
+		&githubCommentArgs.historyCount,
+		"history-count", 5,
+		"most previous runs kept by --history",
+	)
+	githubCommentCmdF.IntVar(
+		&githubCommentArgs.historySize,
+		"history-size", 0,
+		"most bytes of previous runs kept by --history (default: as much as fits in the comment)",
+	)
+	setGithubTemplateArgs(githubCommentCmdF)
 
 	githubCmd.AddCommand(githubPrTrailerCmd)
 	setGithubDefaultArgs(githubPrTrailerCmd.Flags())
+	setGithubTemplateArgs(githubPrTrailerCmd.Flags())
 	githubPrTrailerCmd.Flags().StringVar(
 		&githubPRTrailerArgs.summary,
 		"summary",
 		"",
 		"<summary> for the <details>",
 	)
+	githubPrTrailerCmd.Flags().BoolVar(
+		&githubPRTrailerArgs.remove,
+		"remove", false,
+		"remove the trailer tagged with --key instead of setting it",
+	)
+	githubPrTrailerCmd.Flags().BoolVar(
+		&githubPRTrailerArgs.list,
+		"list", false,
+		"list the trailers on the PR (key and summary)",
+	)
+
+	githubCmd.AddCommand(githubStatusCmd)
+	setGithubDefaultArgs(githubStatusCmd.Flags())
+	githubStatusCmdF := githubStatusCmd.Flags()
+	githubStatusCmdF.StringVar(
+		&githubStatusArgs.state,
+		"state", "",
+		"state of the status (pending, success, error, failure)",
+	)
+	githubStatusCmdF.StringVar(
+		&githubStatusArgs.context,
+		"context", "",
+		"label to differentiate this status from others (ci/my-check)",
+	)
+	githubStatusCmdF.StringVar(
+		&githubStatusArgs.description,
+		"description", "",
+		"short description of the status",
+	)
+	githubStatusCmdF.StringVar(
+		&githubStatusArgs.targetURL,
+		"target-url", "",
+		"url to link from the status",
+	)
+
+	githubCmd.AddCommand(githubLabelsCmd)
+	setGithubDefaultArgs(githubLabelsCmd.Flags())
+	githubLabelsCmd.Flags().StringSliceVar(
+		&githubLabelsArgs.add,
+		"add", nil,
+		"comma separated labels to add",
+	)
+	githubLabelsCmd.Flags().StringSliceVar(
+		&githubLabelsArgs.remove,
+		"remove", nil,
+		"comma separated labels to remove",
+	)
+
+	githubCmd.AddCommand(githubReviewCmd)
+	setGithubDefaultArgs(githubReviewCmd.Flags())
+	githubReviewCmd.Flags().StringVar(
+		&githubReviewArgs.summary,
+		"summary", "",
+		"body of the review (defaults to a count of the findings)",
+	)
 }
 
 var githubCmd = &cobra.Command{
@@ -67,15 +266,10 @@ var githubCmd = &cobra.Command{
 var githubCommentCmd = &cobra.Command{
 	Use:   "comment <file>",
 	Short: "comment on github from file (can be - for stdin)",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.RangeArgs(0, 1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
-		body, err := readFileOrStdin(args[0])
-		if err != nil {
-			return fmt.Errorf("unable to read file: %w", err)
-		}
-
 		key := githubDefaultArgs.key
 
 		repo := githubDefaultArgs.repo
@@ -84,29 +278,126 @@ var githubCommentCmd = &cobra.Command{
 		}
 
 		sha := githubDefaultArgs.sha
-		prNumber := githubDefaultArgs.pr
-		if prNumber != 0 {
-			return github.CommentOnIssue(ctx, repo, prNumber, string(body), key)
-		} else if sha != "" {
-			return github.CommentOnCommit(ctx, repo, sha, string(body), key)
-		} else {
+		prNumber := githubCommentPR(cmd)
+		if prNumber == 0 && sha == "" {
 			return errors.New("either --pr or --sha must be set")
 		}
+
+		if githubCommentArgs.delete && githubCommentArgs.minimize {
+			return errors.New("only one of --delete or --minimize can be set")
+		}
+		if githubCommentArgs.delete {
+			if prNumber != 0 {
+				return github.DeleteIssueComment(ctx, repo, prNumber, key)
+			}
+			return github.DeleteCommitComment(ctx, repo, sha, key)
+		}
+		if githubCommentArgs.minimize {
+			if prNumber != 0 {
+				return github.MinimizeIssueComment(ctx, repo, prNumber, key)
+			}
+			return github.MinimizeCommitComment(ctx, repo, sha, key)
+		}
+
+		body, err := readBody(args)
+		if err != nil {
+			return err
+		}
+
+		opts := &github.CommentOptions{
+			MinimizePrevious: githubCommentArgs.minimizePrevious,
+			Split:            githubCommentArgs.split,
+			History:          githubCommentArgs.history,
+			HistoryCount:     githubCommentArgs.historyCount,
+			HistorySize:      githubCommentArgs.historySize,
+			SHA:              sha,
+		}
+		if prNumber != 0 {
+			return github.CommentOnIssueWithOptions(ctx, repo, prNumber, body, key, opts)
+		}
+		return github.CommentOnCommitWithOptions(ctx, repo, sha, body, key, opts)
 	},
 }
 
 var githubPrTrailerCmd = &cobra.Command{
 	Use:   "pr-trailer <file>",
 	Short: "add text to the bottom of the PR from a file (can be - for stdin)",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.RangeArgs(0, 1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
-		body, err := readFileOrStdin(args[0])
+		repo := githubDefaultArgs.repo
+		if repo == "" {
+			return errors.New("repo must be set")
+		}
+		prNumber := githubDefaultArgs.pr
+		if prNumber == 0 {
+			return errors.New("pr must be set")
+		}
+		key := githubDefaultArgs.key
+
+		if githubPRTrailerArgs.list {
+			trailers, err := github.ListPRTrailers(ctx, repo, prNumber)
+			if err != nil {
+				return err
+			}
+			for _, trailer := range trailers {
+				fmt.Printf("%s\t%s\n", trailer.Key, trailer.Summary)
+			}
+			return nil
+		}
+		if githubPRTrailerArgs.remove {
+			return github.RemovePRTrailer(ctx, repo, prNumber, key)
+		}
+
+		body, err := readBody(args)
 		if err != nil {
-			return fmt.Errorf("unable to read file: %w", err)
+			return err
+		}
+
+		err = github.SetPRTrailerDetails(ctx, repo, prNumber, githubPRTrailerArgs.summary, body, key)
+		return err
+	},
+}
+
+var githubStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "set a commit status on --sha",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		repo := githubDefaultArgs.repo
+		if repo == "" {
+			return errors.New("repo must be set")
+		}
+		sha := githubDefaultArgs.sha
+		if sha == "" {
+			return errors.New("sha must be set")
+		}
+		if githubStatusArgs.state == "" {
+			return errors.New("state must be set")
+		}
+		if githubStatusArgs.context == "" {
+			return errors.New("context must be set")
 		}
 
+		return github.SetCommitStatus(ctx,
+			repo,
+			sha,
+			githubStatusArgs.state,
+			githubStatusArgs.context,
+			githubStatusArgs.description,
+			githubStatusArgs.targetURL,
+		)
+	},
+}
+
+var githubLabelsCmd = &cobra.Command{
+	Use:   "labels",
+	Short: "add or remove labels on --pr",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		repo := githubDefaultArgs.repo
 		if repo == "" {
 			return errors.New("repo must be set")
@@ -115,13 +406,101 @@ var githubPrTrailerCmd = &cobra.Command{
 		if prNumber == 0 {
 			return errors.New("pr must be set")
 		}
-		key := githubDefaultArgs.key
+		if len(githubLabelsArgs.add) == 0 && len(githubLabelsArgs.remove) == 0 {
+			return errors.New("either --add or --remove must be set")
+		}
+		return github.SetLabels(cmd.Context(), repo, prNumber, githubLabelsArgs.add, githubLabelsArgs.remove)
+	},
+}
 
-		err = github.SetPRTrailerDetails(ctx, repo, prNumber, githubPRTrailerArgs.summary, string(body), key)
-		return err
+var githubReviewCmd = &cobra.Command{
+	Use:   "review <file>",
+	Short: "post findings (golangci-lint json, sarif or ndjson) as PR review comments on the lines in the diff",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		repo := githubDefaultArgs.repo
+		if repo == "" {
+			return errors.New("repo must be set")
+		}
+		prNumber := githubDefaultArgs.pr
+		if prNumber == 0 {
+			return errors.New("pr must be set")
+		}
+
+		found, err := findings.Load(args[0])
+		if err != nil {
+			return fmt.Errorf("unable to load findings: %w", err)
+		}
+
+		var comments []github.ReviewComment
+		for _, finding := range found {
+			comments = append(comments, github.ReviewComment{
+				Path: finding.File,
+				Line: finding.Line,
+				Body: findingMarkdown(finding),
+			})
+		}
+
+		summary := githubReviewArgs.summary
+		if summary == "" {
+			summary = fmt.Sprintf("%d findings", len(found))
+		}
+		res, err := github.SubmitReview(ctx, repo, prNumber, githubDefaultArgs.sha, summary, comments, githubDefaultArgs.key)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created %d, updated %d, unchanged %d, resolved %d, outside the diff %d\n",
+			res.Created, res.Updated, res.Unchanged, res.Resolved, res.OutsideDiff)
+		return nil
 	},
 }
 
+func findingMarkdown(finding *findings.Finding) string {
+	icon := "⚠️"
+	switch finding.Severity {
+	case findings.SeverityError:
+		icon = "❌"
+	case findings.SeverityNotice:
+		icon = "ℹ️"
+	}
+	if finding.Rule == "" {
+		return fmt.Sprintf("%s %s", icon, finding.Message)
+	}
+	return fmt.Sprintf("%s **%s**: %s", icon, finding.Rule, finding.Message)
+}
+
+// readBody returns the rendered --template, or the content of the file in args
+func readBody(args []string) (string, error) {
+	if githubTemplateArgs.template == "" {
+		if len(args) != 1 {
+			return "", errors.New("file must be set")
+		}
+		body, err := readFileOrStdin(args[0])
+		if err != nil {
+			return "", fmt.Errorf("unable to read file: %w", err)
+		}
+		return string(body), nil
+	}
+
+	if len(args) != 0 {
+		return "", errors.New("only one of file or --template can be set")
+	}
+	var data interface{}
+	if githubTemplateArgs.data != "" {
+		raw, err := readFileOrStdin(githubTemplateArgs.data)
+		if err != nil {
+			return "", fmt.Errorf("unable to read data: %w", err)
+		}
+		data, err = render.ParseData(raw)
+		if err != nil {
+			return "", err
+		}
+	}
+	return render.RenderFile(githubTemplateArgs.template, render.NewData(data, ciContext, githubTemplateArgs.env))
+}
+
 func readFileOrStdin(path string) ([]byte, error) {
 	var input io.ReadCloser
 	var err error
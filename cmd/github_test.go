@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGithubCommentPR(t *testing.T) {
+	args := githubDefaultArgs
+	t.Cleanup(func() { githubDefaultArgs = args })
+
+	tests := []struct {
+		name  string
+		flags []string
+		pr    int
+	}{
+		{name: "pr from ci", flags: nil, pr: 12},
+		{name: "explicit sha beats ci pr", flags: []string{"--sha", "abc"}, pr: 0},
+		{name: "explicit pr and sha", flags: []string{"--sha", "abc", "--pr", "3"}, pr: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			setGithubDefaultArgs(cmd.Flags())
+			githubDefaultArgs.pr = 12
+			require.NoError(t, cmd.Flags().Parse(tt.flags))
+			require.Equal(t, tt.pr, githubCommentPR(cmd))
+		})
+	}
+}
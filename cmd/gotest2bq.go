@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/alexgartner-bc/ci-multitool/github"
 	"github.com/alexgartner-bc/ci-multitool/gotest2bq"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
 )
 
 func init() {
@@ -15,48 +19,121 @@ func init() {
 	fs.String("project", "", "bigquery project")
 	fs.String("dataset", "", "bigquery dataset")
 	fs.String("table", "", "bigquery table")
-	fs.String("branch", "", "branch name")
+	fs.String("branch", ciContext.Branch, "branch name")
 	fs.String("env", "", "environment")
-	fs.String("commit", "", "commit hash")
+	fs.String("commit", ciContext.SHA, "commit hash")
+	fs.StringSliceP("destinations", "d", []string{"bigquery"}, "comma separated list of destinations (bigquery,gh-step-summary,gh-annotations)")
+	setLabelRuleArgs(fs, []string{"failed", "flaky"})
+	setGithubDefaultArgs(fs)
+	setGithubReportStatusArgs(fs)
 }
 
 var gotest2bqCmd = &cobra.Command{
 	Use:   "gotest2bq",
-	Short: "ingest go test output into bigquery",
+	Short: "ingest go test json output into bigquery or report it to github actions",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		project, _ := cmd.Flags().GetString("project")
-		dataset, _ := cmd.Flags().GetString("dataset")
-		table, _ := cmd.Flags().GetString("table")
-
-		if project == "" || dataset == "" || table == "" {
-			return errors.New("project, dataset, and table are required")
-		}
-
+		destinations, _ := cmd.Flags().GetStringSlice("destinations")
 		branch, _ := cmd.Flags().GetString("branch")
 		env, _ := cmd.Flags().GetString("env")
 		commit, _ := cmd.Flags().GetString("commit")
-
 		filename := args[0]
-		goTest2BqArgs := gotest2bq.GoTest2BQArgs{
-			Branch:   branch,
-			Env:      env,
-			Commit:   commit,
-			Filename: filename,
-			Project:  project,
-			Dataset:  dataset,
-			Table:    table,
-		}
-		// the bigquery api is very eventually consistent. You will often get a 404 after creating or updating a table.
-		var err error
-		for i := 0; i < 3; i++ {
-			err = gotest2bq.GoTest2BQ(goTest2BqArgs)
-			if err == nil {
-				break
+
+		if slices.Contains(destinations, "bigquery") {
+			project, _ := cmd.Flags().GetString("project")
+			dataset, _ := cmd.Flags().GetString("dataset")
+			table, _ := cmd.Flags().GetString("table")
+
+			if project == "" || dataset == "" || table == "" {
+				return errors.New("project, dataset, and table are required")
+			}
+
+			goTest2BqArgs := gotest2bq.GoTest2BQArgs{
+				Branch:   branch,
+				Env:      env,
+				Commit:   commit,
+				Filename: filename,
+				Project:  project,
+				Dataset:  dataset,
+				Table:    table,
+			}
+			// the bigquery api is very eventually consistent. You will often get a 404 after creating or updating a table.
+			var err error
+			for i := 0; i < 3; i++ {
+				err = gotest2bq.GoTest2BQ(goTest2BqArgs)
+				if err == nil {
+					break
+				}
+				fmt.Fprintf(os.Stderr, "got error, will retry: %v\n", err)
+				time.Sleep(time.Second * 2)
+			}
+			if err != nil {
+				return err
 			}
-			fmt.Fprintf(os.Stderr, "got error, will retry: %v\n", err)
-			time.Sleep(time.Second * 2)
 		}
-		return err
+
+		if githubDefaultArgs.sha == "" {
+			githubDefaultArgs.sha = commit
+		}
+		results, err := gotest2bq.LoadTestResults(filename)
+		if err != nil {
+			return err
+		}
+
+		if slices.Contains(destinations, "gh-step-summary") {
+			err = github.AppendStepSummary(results.Markdown())
+			if err != nil {
+				return err
+			}
+		}
+		if slices.Contains(destinations, "gh-annotations") {
+			modulePath := readModulePath()
+			var annotations []github.Annotation
+			for _, failure := range results.Failures {
+				annotations = append(annotations, github.Annotation{
+					Level:   github.AnnotationError,
+					File:    failure.RepoPath(modulePath),
+					Line:    failure.Line,
+					Title:   failure.Name(),
+					Message: failure.Output,
+				})
+			}
+			err = github.WriteAnnotations(os.Stdout, annotations)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = applyLabelRules(cmd.Context(), map[string]bool{
+			"failed": len(results.Failed) > 0,
+			"flaky":  len(results.Flaky) > 0,
+		})
+		if err != nil {
+			return err
+		}
+
+		statusState := github.StatusSuccess
+		if len(results.Failed) > 0 {
+			statusState = github.StatusFailure
+		}
+		return publishGithubReportStatus(cmd.Context(), statusState, results.ShortSummaryString())
 	},
 }
+
+// readModulePath returns the module path from ./go.mod (if any) so test files can be mapped to repo paths
+func readModulePath() string {
+	file, err := os.Open("go.mod")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/alexgartner-bc/ci-multitool/jira"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	jiraCmd.AddCommand(jiraConvertCmd)
+	jiraConvertCmd.Flags().String("to", "wiki", "output format: wiki (server, data center and the v2 api) or adf (atlassian document format json for the cloud v3 api)")
+}
+
+var jiraConvertCmd = &cobra.Command{
+	Use:          "convert <markdown file or - for stdin>",
+	Short:        "convert github flavored markdown to jira wiki markup or atlassian document format",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, _ := cmd.Flags().GetString("to")
+		body, err := readFileOrStdin(args[0])
+		if err != nil {
+			return fmt.Errorf("unable to read file: %w", err)
+		}
+
+		switch to {
+		case "wiki":
+			fmt.Println(jira.MarkdownToWiki(string(body)))
+			return nil
+		case "adf":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(jira.MarkdownToADF(string(body)))
+		default:
+			return fmt.Errorf("invalid format %q (wiki, adf)", to)
+		}
+	},
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alexgartner-bc/ci-multitool/jira"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	jiraCmd.AddCommand(jiraCommentCmd)
+	jiraCmd.AddCommand(jiraAttachCmd)
+	jiraCmd.AddCommand(jiraLinkCmd)
+
+	jiraCommentCmd.Flags().String("format", jira.FormatWiki, "comment format (wiki, markdown). markdown is converted to wiki markup")
+
+	fs := jiraLinkCmd.Flags()
+	fs.String("url", ciContext.RunURL, "url to link, defaults to the ci build")
+	fs.String("title", "", "link title, defaults to the url")
+}
+
+var jiraCommentCmd = &cobra.Command{
+	Use:          "comment <key> <file or - for stdin>",
+	Short:        "comment on a jira issue",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		commonArgs, err := getCommonArgs(cmd)
+		if err != nil {
+			return err
+		}
+		body, err := readFileOrStdin(args[1])
+		if err != nil {
+			return fmt.Errorf("unable to read file: %w", err)
+		}
+		format, _ := cmd.Flags().GetString("format")
+		text, err := jira.ToWiki(string(body), format)
+		if err != nil {
+			return err
+		}
+		return jira.AddComment(commonArgs, args[0], text)
+	},
+}
+
+var jiraAttachCmd = &cobra.Command{
+	Use:          "attach <key> <files...>",
+	Short:        "upload files (logs, reports) to a jira issue",
+	Args:         cobra.MinimumNArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		commonArgs, err := getCommonArgs(cmd)
+		if err != nil {
+			return err
+		}
+		return jira.AttachFiles(commonArgs, args[0], args[1:])
+	},
+}
+
+var jiraLinkCmd = &cobra.Command{
+	Use:          "link <key>",
+	Short:        "link a jira issue to a url (pull request, ci build)",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		commonArgs, err := getCommonArgs(cmd)
+		if err != nil {
+			return err
+		}
+		url, _ := cmd.Flags().GetString("url")
+		if url == "" {
+			return errors.New("url is required")
+		}
+		title, _ := cmd.Flags().GetString("title")
+		return jira.AddRemoteLink(commonArgs, args[0], url, title)
+	},
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alexgartner-bc/ci-multitool/jira"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	jiraCmd.AddCommand(jiraTransitionCmd)
+	jiraCmd.AddCommand(jiraResolveCmd)
+
+	fs := jiraTransitionCmd.Flags()
+	fs.String("to", "", "transition or status name (e.g. Done)")
+	fs.String("resolution", "", "resolution, if the transition has one (defaults to Done when it is required)")
+	fs.String("comment", "", "comment to add with the transition")
+
+	fs = jiraResolveCmd.Flags()
+	fs.String("dedupe-key", "", "dedupe key the issues were created with (create-issue --dedupe-key)")
+	fs.String("to", "Done", "transition or status name")
+	fs.String("resolution", "", "resolution, if the transition has one (defaults to Done when it is required)")
+	fs.String("comment", "", "comment to add with the transition")
+}
+
+var jiraTransitionCmd = &cobra.Command{
+	Use:          "transition <key>",
+	Short:        "move a jira issue to another status",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		commonArgs, err := getCommonArgs(cmd)
+		if err != nil {
+			return err
+		}
+		to, _ := cmd.Flags().GetString("to")
+		if to == "" {
+			return errors.New("to is required")
+		}
+		resolution, _ := cmd.Flags().GetString("resolution")
+		comment, _ := cmd.Flags().GetString("comment")
+
+		return jira.TransitionIssue(&jira.TransitionArgs{
+			Common:     commonArgs,
+			Key:        args[0],
+			To:         to,
+			Resolution: resolution,
+			Comment:    comment,
+		})
+	},
+}
+
+var jiraResolveCmd = &cobra.Command{
+	Use:          "resolve",
+	Short:        "close the open issues created with a dedupe key, once the failure is gone",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		commonArgs, err := getCommonArgs(cmd)
+		if err != nil {
+			return err
+		}
+		dedupeKey, _ := cmd.Flags().GetString("dedupe-key")
+		if dedupeKey == "" {
+			return errors.New("dedupe-key is required")
+		}
+		to, _ := cmd.Flags().GetString("to")
+		resolution, _ := cmd.Flags().GetString("resolution")
+		comment, _ := cmd.Flags().GetString("comment")
+
+		keys, err := jira.ResolveIssuesByDedupeKey(&jira.ResolveArgs{
+			Common:     commonArgs,
+			DedupeKey:  dedupeKey,
+			To:         to,
+			Resolution: resolution,
+			Comment:    comment,
+		})
+		for _, key := range keys {
+			fmt.Println(key)
+		}
+		return err
+	},
+}
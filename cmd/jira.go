@@ -4,8 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
 	"github.com/alexgartner-bc/ci-multitool/jira"
 	"github.com/spf13/cobra"
 )
@@ -16,17 +19,22 @@ func init() {
 	jiraCmdF.String("instance-url", os.Getenv("JIRA_INSTANCE_URL"), "instance url")
 	jiraCmdF.String("project", os.Getenv("JIRA_PROJECT"), "project")
 	jiraCmdF.String("user", os.Getenv("JIRA_USER"), "user")
-	jiraCmdF.String("password", os.Getenv("JIRA_PASSWORD"), "password/token")
+	jiraCmdF.String("password", os.Getenv("JIRA_PASSWORD"), "password/api token (basic auth, cloud)")
+	jiraCmdF.String("token", os.Getenv("JIRA_TOKEN"), "personal access token (bearer auth, data center), instead of user and password")
+	jiraCmdF.String("user-map", os.Getenv("JIRA_USER_MAP"), "yaml or json file mapping github logins to jira users (optional)")
 	jiraCmdF.String("board", os.Getenv("JIRA_BOARD"), "board id num (optional)")
 
 	jiraCreateIssueCmdF := jiraCreateIssueCmd.Flags()
 	jiraCreateIssueCmdF.StringP("summary", "s", "", "issue summary/title")
 	jiraCreateIssueCmdF.StringP("description", "d", "", "issue description")
+	jiraCreateIssueCmdF.String("description-file", "", "read the description from a file (- for stdin)")
+	jiraCreateIssueCmdF.String("format", jira.FormatWiki, "description format (wiki, markdown). markdown is converted to wiki markup")
 	jiraCreateIssueCmdF.StringP("assignee", "a", "", "issue assignee")
 	jiraCreateIssueCmdF.StringP("type", "t", "", "issue type")
 	jiraCreateIssueCmdF.StringSliceP("labels", "l", []string{}, "issue labels")
-	jiraCreateIssueCmdF.StringToString("custom", map[string]string{}, "issue custom fields")
+	jiraCreateIssueCmdF.StringArray("custom", []string{}, "custom field as name=value, the name can be the field id (repeatable). arrays are comma separated, cascading selects parent>child, json is sent as is")
 	jiraCreateIssueCmdF.StringSlice("components", []string{}, "issue components (repeatable)")
+	jiraCreateIssueCmdF.String("dedupe-key", "", "comment on the open issue created with the same key instead of creating another one (e.g. the job name)")
 }
 
 var jiraCmd = &cobra.Command{
@@ -44,12 +52,24 @@ func getCommonArgs(cmd *cobra.Command) (*jira.CommonArgs, error) {
 		return nil, errors.New("project is required")
 	}
 	user, _ := cmd.Flags().GetString("user")
-	if user == "" {
-		return nil, errors.New("user is required")
-	}
 	password, _ := cmd.Flags().GetString("password")
-	if password == "" {
-		return nil, errors.New("password is required")
+	token, _ := cmd.Flags().GetString("token")
+	if token == "" && !dryrun.Enabled {
+		if user == "" {
+			return nil, errors.New("user is required (or token)")
+		}
+		if password == "" {
+			return nil, errors.New("password is required (or token)")
+		}
+	}
+	var userMap map[string]string
+	userMapPath, _ := cmd.Flags().GetString("user-map")
+	if userMapPath != "" {
+		var err error
+		userMap, err = jira.LoadUserMap(userMapPath)
+		if err != nil {
+			return nil, err
+		}
 	}
 	// board is optional
 	board, _ := cmd.Flags().GetString("board")
@@ -62,7 +82,9 @@ func getCommonArgs(cmd *cobra.Command) (*jira.CommonArgs, error) {
 		Project:     project,
 		User:        user,
 		Password:    password,
+		Token:       token,
 		Board:       boardInt,
+		UserMap:     userMap,
 	}, nil
 }
 
@@ -90,17 +112,25 @@ var jiraCreateIssueCmd = &cobra.Command{
 		if summary == "" {
 			return errors.New("summary is required")
 		}
-		description, _ := cmd.Flags().GetString("description")
+		description, err := readDescription(cmd)
+		if err != nil {
+			return err
+		}
 		assignee, _ := cmd.Flags().GetString("assignee")
 		issueType, _ := cmd.Flags().GetString("type")
 		if issueType == "" {
 			return errors.New("type is required")
 		}
 		labels, _ := cmd.Flags().GetStringSlice("labels")
-		customFields, _ := cmd.Flags().GetStringToString("custom")
+		customFieldArgs, _ := cmd.Flags().GetStringArray("custom")
+		customFields, err := parseCustomFields(customFieldArgs)
+		if err != nil {
+			return err
+		}
 		components, _ := cmd.Flags().GetStringSlice("components")
+		dedupeKey, _ := cmd.Flags().GetString("dedupe-key")
 
-		key, err := jira.CreateIssue(&jira.CreateIssueArgs{
+		key, created, err := jira.CreateOrCommentIssue(&jira.CreateIssueArgs{
 			Common:       commonArgs,
 			Summary:      summary,
 			Description:  description,
@@ -109,6 +139,7 @@ var jiraCreateIssueCmd = &cobra.Command{
 			Labels:       labels,
 			CustomFields: customFields,
 			Components:   components,
+			DedupeKey:    dedupeKey,
 		})
 		if err != nil {
 			return err
@@ -116,8 +147,12 @@ var jiraCreateIssueCmd = &cobra.Command{
 		fmt.Println(key)
 
 		// If board was given, get the active sprint ID and add issue to sprint
-		if commonArgs.Board != 0 {
+		if created && commonArgs.Board != 0 {
 			sprint, err := jira.GetActiveSprint(commonArgs)
+			if err != nil && dryrun.Enabled {
+				dryrun.Printf("add %s to the active sprint of board %d (lookup failed: %v)\n", key, commonArgs.Board, err)
+				return nil
+			}
 			if err != nil {
 				return err
 			}
@@ -129,3 +164,41 @@ var jiraCreateIssueCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// readDescription reads --description or --description-file and converts it from --format to wiki markup
+func readDescription(cmd *cobra.Command) (string, error) {
+	description, _ := cmd.Flags().GetString("description")
+	descriptionFile, _ := cmd.Flags().GetString("description-file")
+	if descriptionFile != "" {
+		if description != "" {
+			return "", errors.New("only one of description or description-file can be set")
+		}
+		body, err := readFileOrStdin(descriptionFile)
+		if err != nil {
+			return "", fmt.Errorf("unable to read description: %w", err)
+		}
+		description = string(body)
+	}
+	format, _ := cmd.Flags().GetString("format")
+	return jira.ToWiki(description, format)
+}
+
+// customFieldPairRegex matches a second name=value pair in a value, --custom used to take a=1,b=2
+var customFieldPairRegex = regexp.MustCompile(`,\s*[\w.-]+=`)
+
+// parseCustomFields parses name=value flags
+func parseCustomFields(args []string) (map[string]string, error) {
+	customFields := make(map[string]string, len(args))
+	for _, arg := range args {
+		name, value, found := strings.Cut(arg, "=")
+		if !found || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid custom field %q, expected name=value", arg)
+		}
+		isJSON := strings.HasPrefix(strings.TrimSpace(value), "{") || strings.HasPrefix(strings.TrimSpace(value), "[")
+		if !isJSON && customFieldPairRegex.MatchString(value) {
+			return nil, fmt.Errorf("custom field %q looks like several name=value pairs, repeat --custom for each field", arg)
+		}
+		customFields[strings.TrimSpace(name)] = value
+	}
+	return customFields, nil
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCustomFields(t *testing.T) {
+	fields, err := parseCustomFields([]string{"Team=core", "labels=a,b", "customfield_10001={\"value\": \"x,y=z\"}"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"Team": "core", "labels": "a,b", "customfield_10001": "{\"value\": \"x,y=z\"}"}, fields)
+
+	// the old comma separated form must not silently become one field
+	_, err = parseCustomFields([]string{"a=1,b=2"})
+	require.ErrorContains(t, err, "repeat --custom")
+
+	_, err = parseCustomFields([]string{"novalue"})
+	require.Error(t, err)
+}
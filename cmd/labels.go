@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alexgartner-bc/ci-multitool/github"
+	"github.com/spf13/pflag"
+	"golang.org/x/exp/slices"
+)
+
+var labelRuleArgs = struct {
+	rules []string
+}{}
+
+// setLabelRuleArgs adds the --label-rule flag to a report command which supports conditions
+func setLabelRuleArgs(fs *pflag.FlagSet, conditions []string) {
+	fs.StringArrayVar(
+		&labelRuleArgs.rules,
+		"label-rule", nil,
+		fmt.Sprintf("label=condition. add the label to --pr when the condition holds and remove it when it doesn't (%s). can be repeated", strings.Join(conditions, ", ")),
+	)
+}
+
+type labelRule struct {
+	label     string
+	condition string
+}
+
+func parseLabelRules(rules []string, conditions []string) ([]labelRule, error) {
+	var res []labelRule
+	for _, rule := range rules {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --label-rule %q (label=condition)", rule)
+		}
+		if !slices.Contains(conditions, parts[1]) {
+			return nil, fmt.Errorf("invalid --label-rule condition %q (%s)", parts[1], strings.Join(conditions, ", "))
+		}
+		res = append(res, labelRule{label: parts[0], condition: parts[1]})
+	}
+	return res, nil
+}
+
+// applyLabelRules adds or removes the label of each --label-rule depending on whether its condition holds
+func applyLabelRules(ctx context.Context, conditions map[string]bool) error {
+	if len(labelRuleArgs.rules) == 0 {
+		return nil
+	}
+	var names []string
+	for name := range conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rules, err := parseLabelRules(labelRuleArgs.rules, names)
+	if err != nil {
+		return err
+	}
+
+	repo := githubDefaultArgs.repo
+	if repo == "" {
+		return errors.New("repo must be set")
+	}
+	prNumber := githubDefaultArgs.pr
+	if prNumber == 0 {
+		return errors.New("pr must be set for --label-rule")
+	}
+
+	var add, remove []string
+	for _, rule := range rules {
+		if conditions[rule.condition] {
+			add = append(add, rule.label)
+		} else {
+			remove = append(remove, rule.label)
+		}
+	}
+	err = github.SetLabels(ctx, repo, prNumber, add, remove)
+	if err != nil {
+		return fmt.Errorf("unable to set labels: %w", err)
+	}
+	return nil
+}
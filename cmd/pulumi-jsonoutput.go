@@ -1,16 +1,26 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/alexgartner-bc/ci-multitool/github"
 	"github.com/alexgartner-bc/ci-multitool/pulumi/jsonoutput"
+	"github.com/alexgartner-bc/ci-multitool/scm"
 	"github.com/spf13/cobra"
 	"golang.org/x/exp/slices"
 )
 
 var pulumiJSONOutputFlags = struct {
 	destinations []string
+	onUnchanged  string
+	history      bool
+	scm          string
+
+	deploymentID             int64
+	deploymentEnvironmentURL string
 }{
 	destinations: []string{},
 }
@@ -20,9 +30,36 @@ func init() {
 		&pulumiJSONOutputFlags.destinations,
 		"destinations", "d",
 		[]string{},
-		"comma separated list of destinations (stdout,gh-comment,gh-pr-trailer)",
+		"comma separated list of destinations (stdout,gh-comment,gh-pr-trailer,gh-step-summary,gh-annotations)",
+	)
+	pulumiJSONOutput.Flags().StringVar(
+		&pulumiJSONOutputFlags.onUnchanged,
+		"on-unchanged", "",
+		"what to do with an existing gh-comment when the preview is unchanged (delete, minimize). default: update it",
+	)
+	pulumiJSONOutput.Flags().BoolVar(
+		&pulumiJSONOutputFlags.history,
+		"history", false,
+		"keep previous previews in a collapsed section of the gh-comment",
+	)
+	pulumiJSONOutput.Flags().StringVar(
+		&pulumiJSONOutputFlags.scm,
+		"scm", scm.Detect(ciContext),
+		"where gh-comment and gh-pr-trailer are posted (github, gitlab, gitea). detected from ci if not set",
 	)
+	pulumiJSONOutput.Flags().Int64Var(
+		&pulumiJSONOutputFlags.deploymentID,
+		"deployment-id", 0,
+		"set the status of this github deployment to success or failure from the result (optional)",
+	)
+	pulumiJSONOutput.Flags().StringVar(
+		&pulumiJSONOutputFlags.deploymentEnvironmentURL,
+		"deployment-environment-url", "",
+		"url of the deployed environment for --deployment-id",
+	)
+	setLabelRuleArgs(pulumiJSONOutput.Flags(), []string{"destructive", "changes", "unchanged", "error"})
 	setGithubDefaultArgs(pulumiJSONOutput.Flags())
+	setGithubReportStatusArgs(pulumiJSONOutput.Flags())
 }
 
 var pulumiJSONOutput = &cobra.Command{
@@ -32,6 +69,11 @@ var pulumiJSONOutput = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 		destinations := pulumiJSONOutputFlags.destinations
+		switch pulumiJSONOutputFlags.onUnchanged {
+		case "", "delete", "minimize":
+		default:
+			return fmt.Errorf("invalid --on-unchanged %q (delete, minimize)", pulumiJSONOutputFlags.onUnchanged)
+		}
 
 		m, err := jsonoutput.NewManagerFromFile(args[0])
 		if err != nil {
@@ -40,6 +82,8 @@ var pulumiJSONOutput = &cobra.Command{
 		summary := m.ShortSummaryString()
 		errMessage := m.Error()
 		tree := m.TreeString()
+		// leave room for the summary and errors, comments and PR bodies are limited in size
+		ghTree := m.TreeStringLimit(github.MaxBodyLength - len(errMessage) - 1024)
 
 		if slices.Contains(destinations, "stdout") {
 			fmt.Println("Summary: " + summary + "\n")
@@ -48,13 +92,32 @@ var pulumiJSONOutput = &cobra.Command{
 			}
 			fmt.Println(tree)
 		}
+		var backend scm.Backend
+		if slices.Contains(destinations, "gh-comment") || slices.Contains(destinations, "gh-pr-trailer") {
+			if githubDefaultArgs.repo == "" {
+				return errors.New("repo must be set")
+			}
+			backend, err = pulumiSCMBackend()
+			if err != nil {
+				return err
+			}
+		}
+		if slices.Contains(destinations, "gh-comment") {
+			err = pulumiGithubComment(ctx, backend, !m.HasChanges(), summary, errMessage, ghTree)
+			if err != nil {
+				return err
+			}
+		}
 		if slices.Contains(destinations, "gh-pr-trailer") {
+			if githubDefaultArgs.pr == 0 {
+				return errors.New("pr must be set for gh-pr-trailer")
+			}
 			ghSummary := fmt.Sprintf("pulumi output (%s)", summary)
-			ghDetails := fmt.Sprintf("```\n%s```", tree)
+			ghDetails := fmt.Sprintf("```\n%s```", ghTree)
 			if errMessage != "" {
 				ghDetails = fmt.Sprintf("```\n%s\n```\n%s", errMessage, ghDetails)
 			}
-			err = github.SetPRTrailerDetails(ctx,
+			err = backend.SetDescriptionTrailer(ctx,
 				githubDefaultArgs.repo,
 				githubDefaultArgs.pr,
 				ghSummary,
@@ -65,6 +128,138 @@ var pulumiJSONOutput = &cobra.Command{
 				return fmt.Errorf("unable to set github pr trailer: %w", err)
 			}
 		}
-		return nil
+
+		if slices.Contains(destinations, "gh-step-summary") {
+			err = github.AppendStepSummary(pulumiMarkdown(summary, errMessage, ghTree))
+			if err != nil {
+				return err
+			}
+		}
+		if slices.Contains(destinations, "gh-annotations") {
+			var annotations []github.Annotation
+			for _, d := range m.Diagnostics() {
+				if d.Severity != github.AnnotationError && d.Severity != github.AnnotationWarning {
+					continue
+				}
+				title := "pulumi"
+				if d.Urn != "" {
+					title = m.ResourceName(d.Urn)
+				}
+				annotations = append(annotations, github.Annotation{
+					Level:   d.Severity,
+					Title:   title,
+					Message: d.Message,
+				})
+			}
+			err = github.WriteAnnotations(os.Stdout, annotations)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = applyLabelRules(ctx, map[string]bool{
+			"destructive": m.IsDestructive(),
+			"changes":     m.HasChanges(),
+			"unchanged":   !m.HasChanges(),
+			"error":       errMessage != "",
+		})
+		if err != nil {
+			return err
+		}
+
+		err = pulumiDeploymentStatus(ctx, summary, errMessage)
+		if err != nil {
+			return err
+		}
+
+		statusState := github.StatusSuccess
+		if errMessage != "" {
+			statusState = github.StatusFailure
+		}
+		return publishGithubReportStatus(ctx, statusState, summary)
 	},
 }
+
+// pulumiSCMBackend returns the --scm backend. Comment history and minimizing are only supported on github.
+func pulumiSCMBackend() (scm.Backend, error) {
+	if pulumiJSONOutputFlags.scm == scm.NameGithub {
+		return &scm.Github{
+			Options: &github.CommentOptions{
+				History: pulumiJSONOutputFlags.history,
+				SHA:     githubDefaultArgs.sha,
+			},
+		}, nil
+	}
+	if pulumiJSONOutputFlags.history || pulumiJSONOutputFlags.onUnchanged == "minimize" {
+		return nil, fmt.Errorf("--history and --on-unchanged minimize are only supported on github, not %s", pulumiJSONOutputFlags.scm)
+	}
+	return scm.New(pulumiJSONOutputFlags.scm)
+}
+
+// pulumiDeploymentStatus sets the --deployment-id status to success, or failure if pulumi errored
+func pulumiDeploymentStatus(ctx context.Context, summary string, errMessage string) error {
+	if pulumiJSONOutputFlags.deploymentID == 0 {
+		return nil
+	}
+	deploymentState := github.DeploymentStateSuccess
+	if errMessage != "" {
+		deploymentState = github.DeploymentStateFailure
+	}
+	return github.SetDeploymentStatus(ctx,
+		githubDefaultArgs.repo,
+		pulumiJSONOutputFlags.deploymentID,
+		deploymentState,
+		summary,
+		ciContext.RunURL,
+		pulumiJSONOutputFlags.deploymentEnvironmentURL,
+	)
+}
+
+// pulumiGithubComment posts the preview, or applies --on-unchanged when the preview has no changes.
+// Without a pull request (pushes to a branch) the preview is posted on --sha instead.
+func pulumiGithubComment(ctx context.Context, backend scm.Backend, unchanged bool, summary string, errMessage string, tree string) error {
+	repo := githubDefaultArgs.repo
+	number := githubDefaultArgs.pr
+	key := githubDefaultArgs.key
+	if number == 0 && githubDefaultArgs.sha == "" {
+		return errors.New("either --pr or --sha must be set for gh-comment")
+	}
+
+	if number == 0 {
+		err := backend.CommentOnCommit(ctx, repo, githubDefaultArgs.sha, pulumiMarkdown(summary, errMessage, tree), key)
+		if err != nil {
+			return fmt.Errorf("unable to set commit comment: %w", err)
+		}
+		return nil
+	}
+
+	if unchanged && errMessage == "" {
+		switch pulumiJSONOutputFlags.onUnchanged {
+		case "delete":
+			err := backend.DeleteChangeRequestComment(ctx, repo, number, key)
+			if err != nil {
+				return fmt.Errorf("unable to delete github comment: %w", err)
+			}
+			return nil
+		case "minimize":
+			err := github.MinimizeIssueComment(ctx, repo, number, key)
+			if err != nil {
+				return fmt.Errorf("unable to minimize github comment: %w", err)
+			}
+			return nil
+		}
+	}
+
+	err := backend.CommentOnChangeRequest(ctx, repo, number, pulumiMarkdown(summary, errMessage, tree), key)
+	if err != nil {
+		return fmt.Errorf("unable to set github comment: %w", err)
+	}
+	return nil
+}
+
+func pulumiMarkdown(summary string, errMessage string, tree string) string {
+	if errMessage != "" {
+		return fmt.Sprintf("**pulumi output** (%s)\n\n```\n%s\n```\n```\n%s```\n", summary, errMessage, tree)
+	}
+	return fmt.Sprintf("**pulumi output** (%s)\n\n```\n%s```\n", summary, tree)
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexgartner-bc/ci-multitool/pulumi/jsonoutput"
+	"github.com/alexgartner-bc/ci-multitool/scm"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	scm.Backend
+	comments       []string
+	commitComments []string
+	deletes        int
+}
+
+func (f *fakeBackend) CommentOnChangeRequest(ctx context.Context, repo string, number int, text string, stickyKey string) error {
+	f.comments = append(f.comments, text)
+	return nil
+}
+
+func (f *fakeBackend) DeleteChangeRequestComment(ctx context.Context, repo string, number int, stickyKey string) error {
+	f.deletes++
+	return nil
+}
+
+func (f *fakeBackend) CommentOnCommit(ctx context.Context, repo string, sha string, text string, stickyKey string) error {
+	f.commitComments = append(f.commitComments, sha)
+	return nil
+}
+
+func TestPulumiGithubCommentOnCommit(t *testing.T) {
+	args := githubDefaultArgs
+	t.Cleanup(func() { githubDefaultArgs = args })
+	githubDefaultArgs.pr = 0
+	githubDefaultArgs.sha = "abc123"
+
+	backend := &fakeBackend{}
+	require.NoError(t, pulumiGithubComment(context.Background(), backend, false, "1 to create", "", "tree"))
+	require.Equal(t, []string{"abc123"}, backend.commitComments)
+	require.Empty(t, backend.comments)
+
+	githubDefaultArgs.sha = ""
+	require.ErrorContains(t, pulumiGithubComment(context.Background(), backend, false, "1 to create", "", "tree"), "either --pr or --sha must be set")
+}
+
+func TestPulumiGithubCommentOnUnchanged(t *testing.T) {
+	onUnchanged, args := pulumiJSONOutputFlags.onUnchanged, githubDefaultArgs
+	t.Cleanup(func() { pulumiJSONOutputFlags.onUnchanged, githubDefaultArgs = onUnchanged, args })
+	pulumiJSONOutputFlags.onUnchanged = "delete"
+	githubDefaultArgs.pr = 1
+
+	for _, tt := range []struct {
+		file    string
+		deletes int
+	}{
+		{file: "preview-unchanged.json", deletes: 1},
+		{file: "preview-changes2.json", deletes: 0},
+	} {
+		t.Run(tt.file, func(t *testing.T) {
+			m, err := jsonoutput.NewManagerFromFile("../pulumi/jsonoutput/testdata/" + tt.file)
+			require.NoError(t, err)
+			// real previews always count the unchanged resources
+			require.Contains(t, m.ShortSummaryString(), "same")
+
+			backend := &fakeBackend{}
+			err = pulumiGithubComment(context.Background(), backend, !m.HasChanges(), m.ShortSummaryString(), m.Error(), m.TreeString())
+			require.NoError(t, err)
+			require.Equal(t, tt.deletes, backend.deletes)
+			require.Len(t, backend.comments, 1-tt.deletes)
+		})
+	}
+}
+
+func TestPulumiDeploymentStatus(t *testing.T) {
+	var states []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v3/repos/owner/repo/deployments/42/statuses", r.URL.Path)
+		req := struct {
+			State string `json:"state"`
+		}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		states = append(states, req.State)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL+"/api/v3")
+	t.Setenv("GITHUB_TOKEN", "fake")
+	t.Setenv("GITHUB_APP_ID", "")
+
+	flags, args := pulumiJSONOutputFlags, githubDefaultArgs
+	t.Cleanup(func() { pulumiJSONOutputFlags, githubDefaultArgs = flags, args })
+	githubDefaultArgs.repo = "owner/repo"
+
+	// no deployment, nothing to do
+	require.NoError(t, pulumiDeploymentStatus(context.Background(), "same 1", ""))
+	require.Empty(t, states)
+
+	pulumiJSONOutputFlags.deploymentID = 42
+	for _, file := range []string{"preview-changes.json", "error.json"} {
+		m, err := jsonoutput.NewManagerFromFile("../pulumi/jsonoutput/testdata/" + file)
+		require.NoError(t, err)
+		require.NoError(t, pulumiDeploymentStatus(context.Background(), m.ShortSummaryString(), m.Error()))
+	}
+	require.Equal(t, []string{"success", "failure"}, states)
+}
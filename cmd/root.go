@@ -3,14 +3,37 @@ package cmd
 import (
 	"os"
 
+	"github.com/alexgartner-bc/ci-multitool/cicontext"
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
+	"github.com/alexgartner-bc/ci-multitool/github"
 	"github.com/spf13/cobra"
 )
 
+// ciContext provides flag defaults (repo, pr, sha...) detected from the ci environment
+var ciContext = cicontext.Detect()
+
 func init() {
 	rootCmd.AddCommand(pulumiCmd)
 	rootCmd.AddCommand(githubCmd)
 	rootCmd.AddCommand(gotest2bqCmd)
 	rootCmd.AddCommand(jiraCmd)
+	rootCmd.AddCommand(sarifCmd)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&github.Debug,
+		"debug", false,
+		"log github api calls and the remaining rate limit quota to stderr",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&github.TrailerSettleDelay,
+		"trailer-settle-delay", github.TrailerSettleDelay,
+		"how long to wait before checking a pr-trailer edit wasn't overwritten by a concurrent job (0 to skip the check)",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&dryrun.Enabled,
+		"dry-run", false,
+		"print what would be sent to github, gitlab, gitea, jira and bigquery instead of sending it (reads are still made)",
+	)
 }
 
 // rootCmd represents the base command when called without any subcommands
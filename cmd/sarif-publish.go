@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/alexgartner-bc/ci-multitool/github"
+	"github.com/alexgartner-bc/ci-multitool/sarif"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+var sarifPublishFlags = struct {
+	destinations []string
+	checkName    string
+	failOn       string
+}{}
+
+func init() {
+	fs := sarifPublishCmd.Flags()
+	fs.StringSliceVarP(
+		&sarifPublishFlags.destinations,
+		"destinations", "d",
+		[]string{"stdout"},
+		"comma separated list of destinations (stdout,gh-comment,gh-pr-trailer,gh-step-summary,gh-annotations,gh-check-run)",
+	)
+	fs.StringVar(
+		&sarifPublishFlags.checkName,
+		"check-name", "sarif",
+		"name of the gh-check-run",
+	)
+	fs.StringVar(
+		&sarifPublishFlags.failOn,
+		"fail-on", "error",
+		"lowest level which fails the check run and status (error, warning, note, never)",
+	)
+	setGithubDefaultArgs(fs)
+	setGithubReportStatusArgs(fs)
+}
+
+var sarifPublishCmd = &cobra.Command{
+	Use:   "publish <file>...",
+	Short: "report the results in sarif files to github",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		destinations := sarifPublishFlags.destinations
+
+		failOn := sarifPublishFlags.failOn
+		if failOn != "never" && !sarif.IsLevel(failOn) {
+			return fmt.Errorf("invalid --fail-on %q (error, warning, note, never)", failOn)
+		}
+
+		log, err := loadSARIFFiles(args)
+		if err != nil {
+			return err
+		}
+		summary := log.ShortSummaryString()
+		markdown := log.Markdown()
+
+		failed := false
+		var annotations []github.Annotation
+		for _, run := range log.Runs {
+			for _, result := range run.Results {
+				level := run.Level(result)
+				if sarif.LevelAtLeast(level, failOn) {
+					failed = true
+				}
+				file, line := result.Location()
+				title := run.Tool.Driver.Name
+				if result.RuleID != "" {
+					title += " " + result.RuleID
+				}
+				annotations = append(annotations, github.Annotation{
+					Level:   sarifAnnotationLevel(level),
+					File:    file,
+					Line:    line,
+					Title:   title,
+					Message: result.Message.Text,
+				})
+			}
+		}
+
+		if slices.Contains(destinations, "stdout") {
+			fmt.Print(markdown)
+		}
+		if slices.Contains(destinations, "gh-comment") {
+			repo := githubDefaultArgs.repo
+			if prNumber := githubCommentPR(cmd); prNumber != 0 {
+				err = github.CommentOnIssue(ctx, repo, prNumber, markdown, githubDefaultArgs.key)
+			} else if githubDefaultArgs.sha != "" {
+				err = github.CommentOnCommit(ctx, repo, githubDefaultArgs.sha, markdown, githubDefaultArgs.key)
+			} else {
+				err = errors.New("either --pr or --sha must be set")
+			}
+			if err != nil {
+				return fmt.Errorf("unable to set github comment: %w", err)
+			}
+		}
+		if slices.Contains(destinations, "gh-pr-trailer") {
+			err = github.SetPRTrailerDetails(ctx,
+				githubDefaultArgs.repo,
+				githubDefaultArgs.pr,
+				fmt.Sprintf("sarif (%s)", summary),
+				markdown,
+				githubDefaultArgs.key,
+			)
+			if err != nil {
+				return fmt.Errorf("unable to set github pr trailer: %w", err)
+			}
+		}
+		if slices.Contains(destinations, "gh-step-summary") {
+			err = github.AppendStepSummary(markdown)
+			if err != nil {
+				return err
+			}
+		}
+		if slices.Contains(destinations, "gh-annotations") {
+			err = github.WriteAnnotations(os.Stdout, annotations)
+			if err != nil {
+				return err
+			}
+		}
+		if slices.Contains(destinations, "gh-check-run") {
+			if githubDefaultArgs.repo == "" || githubDefaultArgs.sha == "" {
+				return errors.New("repo and sha must be set for gh-check-run")
+			}
+			conclusion := github.CheckConclusionSuccess
+			if failed {
+				conclusion = github.CheckConclusionFailure
+			}
+			err = github.PublishCheckRun(ctx, githubDefaultArgs.repo, githubDefaultArgs.sha, &github.CheckRun{
+				Name:        sarifPublishFlags.checkName,
+				Conclusion:  conclusion,
+				Title:       summary,
+				Summary:     markdown,
+				DetailsURL:  ciContext.RunURL,
+				Annotations: annotations,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		statusState := github.StatusSuccess
+		if failed {
+			statusState = github.StatusFailure
+		}
+		return publishGithubReportStatus(ctx, statusState, summary)
+	},
+}
+
+func sarifAnnotationLevel(level string) string {
+	switch level {
+	case "error":
+		return github.AnnotationError
+	case "warning":
+		return github.AnnotationWarning
+	default:
+		return github.AnnotationNotice
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/alexgartner-bc/ci-multitool/sarif"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	sarifCmd.AddCommand(sarifMergeCmd)
+	sarifCmd.AddCommand(sarifPublishCmd)
+}
+
+var sarifCmd = &cobra.Command{
+	Use:   "sarif",
+	Short: "tools to work with sarif 2.1 output from scanners",
+}
+
+var sarifMergeCmd = &cobra.Command{
+	Use:   "merge <file>...",
+	Short: "merge sarif files (one run per tool, duplicate results removed) and print the result",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log, err := loadSARIFFiles(args)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(log)
+	},
+}
+
+// loadSARIFFiles reads and merges sarif files
+func loadSARIFFiles(filenames []string) (*sarif.Log, error) {
+	var logs []*sarif.Log
+	for _, filename := range filenames {
+		log, err := sarif.Load(filename)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load %s: %w", filename, err)
+		}
+		logs = append(logs, log)
+	}
+	return sarif.Merge(logs...), nil
+}
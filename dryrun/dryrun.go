@@ -0,0 +1,97 @@
+package dryrun
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Enabled makes commands print what they would send instead of changing anything remotely
+var Enabled = false
+
+// Output is where dry runs are printed
+var Output io.Writer = os.Stderr
+
+// Printf prints a dry run message
+func Printf(format string, args ...interface{}) {
+	fmt.Fprintf(Output, "dry-run: "+format, args...)
+}
+
+// Transport sends requests which only read (as decided by IsRead) and prints the others instead, answering them with Response
+type Transport struct {
+	Base http.RoundTripper
+	// IsRead defaults to GET and HEAD requests
+	IsRead func(req *http.Request, body []byte) bool
+	// Response is the body of the fake response to writes (default {})
+	Response func(req *http.Request) string
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	isRead := req.Method == http.MethodGet || req.Method == http.MethodHead
+	if t.IsRead != nil {
+		isRead = t.IsRead(req, body)
+	}
+	if isRead {
+		return base.RoundTrip(req)
+	}
+
+	PrintRequest(req.Method, req.URL.String(), body)
+
+	response := "{}"
+	if t.Response != nil {
+		response = t.Response(req)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(response)),
+		ContentLength: int64(len(response)),
+		Request:       req,
+	}, nil
+}
+
+// PrintRequest prints a request which would have been sent. Json bodies are indented and a "body" text field
+// (comments, descriptions) is also printed as is, so it reads like it would be rendered.
+func PrintRequest(method string, url string, body []byte) {
+	Printf("%s %s\n", method, url)
+	if len(body) == 0 {
+		return
+	}
+	var indented bytes.Buffer
+	if json.Indent(&indented, body, "", "  ") != nil {
+		fmt.Fprintf(Output, "%s\n", body)
+		return
+	}
+	fmt.Fprintf(Output, "%s\n", indented.String())
+
+	fields := struct {
+		Body *string `json:"body"`
+	}{}
+	if json.Unmarshal(body, &fields) == nil && fields.Body != nil {
+		fmt.Fprintf(Output, "--- body ---\n%s\n--- end body ---\n", *fields.Body)
+	}
+}
@@ -0,0 +1,50 @@
+package dryrun
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	var out bytes.Buffer
+	oldOutput := Output
+	Output = &out
+	t.Cleanup(func() {
+		Output = oldOutput
+	})
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"real": true}`)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{}}
+	resp, err := client.Get(srv.URL + "/comments")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, `{"real": true}`, string(body))
+
+	resp, err = client.Post(srv.URL+"/comments", "application/json", strings.NewReader(`{"body":"hello\n<!-- key k -->\n"}`))
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	require.Equal(t, `{}`, string(body))
+	require.Equal(t, 1, requests)
+	require.Equal(t, "dry-run: POST "+srv.URL+"/comments\n"+`{
+  "body": "hello\n<!-- key k -->\n"
+}
+--- body ---
+hello
+<!-- key k -->
+
+--- end body ---
+`, out.String())
+}
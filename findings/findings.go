@@ -0,0 +1,178 @@
+package findings
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexgartner-bc/ci-multitool/sarif"
+)
+
+// Severities, in the same terms as github annotations
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityNotice  = "notice"
+)
+
+// Finding is a problem a linter or scanner found at a line of a file
+type Finding struct {
+	// File is relative to the repo root
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Rule is the linter or rule id which reported the finding (optional)
+	Rule string `json:"rule,omitempty"`
+}
+
+// Load reads findings from a golangci-lint JSON report, a SARIF log or newline delimited JSON findings
+func Load(filename string) ([]*Finding, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse detects the format of data and returns the findings in it
+func Parse(data []byte) ([]*Finding, error) {
+	var res []*Finding
+	var err error
+	switch {
+	case sarif.IsSARIF(data):
+		res, err = parseSARIF(data)
+	case isGolangciLint(data):
+		res, err = parseGolangciLint(data)
+	default:
+		res, err = parseNDJSON(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, finding := range res {
+		finding.File = relativeFile(finding.File)
+		finding.Severity = normalizeSeverity(finding.Severity)
+	}
+	return res, nil
+}
+
+func parseSARIF(data []byte) ([]*Finding, error) {
+	log, err := sarif.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	var res []*Finding
+	for _, run := range log.Runs {
+		for _, result := range run.Results {
+			file, line := result.Location()
+			rule := result.RuleID
+			if rule == "" {
+				rule = run.Tool.Driver.Name
+			}
+			res = append(res, &Finding{
+				File:     file,
+				Line:     line,
+				Severity: run.Level(result),
+				Message:  result.Message.Text,
+				Rule:     rule,
+			})
+		}
+	}
+	return res, nil
+}
+
+type golangciLintReport struct {
+	Issues []struct {
+		FromLinter string
+		Text       string
+		Severity   string
+		Pos        struct {
+			Filename string
+			Line     int
+		}
+	}
+}
+
+func isGolangciLint(data []byte) bool {
+	probe := struct {
+		Issues json.RawMessage
+	}{}
+	err := json.Unmarshal(data, &probe)
+	return err == nil && probe.Issues != nil
+}
+
+func parseGolangciLint(data []byte) ([]*Finding, error) {
+	report := &golangciLintReport{}
+	err := json.Unmarshal(data, report)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode golangci-lint report: %w", err)
+	}
+	var res []*Finding
+	for _, issue := range report.Issues {
+		res = append(res, &Finding{
+			File:     issue.Pos.Filename,
+			Line:     issue.Pos.Line,
+			Severity: issue.Severity,
+			Message:  issue.Text,
+			Rule:     issue.FromLinter,
+		})
+	}
+	return res, nil
+}
+
+// parseNDJSON reads one Finding per line
+func parseNDJSON(data []byte) ([]*Finding, error) {
+	var res []*Finding
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		finding := &Finding{}
+		err := json.Unmarshal(line, finding)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode finding on line %d: %w", lineNumber, err)
+		}
+		res = append(res, finding)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// relativeFile makes absolute paths under the working directory relative to it
+func relativeFile(file string) string {
+	if !filepath.IsAbs(file) {
+		return filepath.ToSlash(filepath.Clean(file))
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return file
+	}
+	rel, err := filepath.Rel(wd, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
+
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "error", "fatal", "critical", "high":
+		return SeverityError
+	case "note", "notice", "info", "none", "low":
+		return SeverityNotice
+	default:
+		return SeverityWarning
+	}
+}
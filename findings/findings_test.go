@@ -0,0 +1,72 @@
+package findings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGolangciLint(t *testing.T) {
+	res, err := Parse([]byte(`{"Issues":[{"FromLinter":"errcheck","Text":"Error return value is not checked","Severity":"","Pos":{"Filename":"cmd/root.go","Line":12,"Column":3}}],"Report":{}}`))
+	require.NoError(t, err)
+	require.Equal(t, []*Finding{{
+		File:     "cmd/root.go",
+		Line:     12,
+		Severity: SeverityWarning,
+		Message:  "Error return value is not checked",
+		Rule:     "errcheck",
+	}}, res)
+}
+
+func TestParseSARIF(t *testing.T) {
+	res, err := Parse([]byte(`{
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {"driver": {"name": "gosec"}},
+    "results": [{
+      "ruleId": "G104",
+      "level": "error",
+      "message": {"text": "Errors unhandled."},
+      "locations": [{"physicalLocation": {"artifactLocation": {"uri": "./github/client.go"}, "region": {"startLine": 40}}}]
+    }]
+  }]
+}`))
+	require.NoError(t, err)
+	require.Equal(t, []*Finding{{
+		File:     "github/client.go",
+		Line:     40,
+		Severity: SeverityError,
+		Message:  "Errors unhandled.",
+		Rule:     "G104",
+	}}, res)
+}
+
+func TestParseNDJSON(t *testing.T) {
+	res, err := Parse([]byte(`{"file":"main.go","line":3,"severity":"info","message":"consider renaming"}
+
+{"file":"go.mod","line":1,"message":"old go version","rule":"gomod"}
+`))
+	require.NoError(t, err)
+	require.Equal(t, []*Finding{
+		{File: "main.go", Line: 3, Severity: SeverityNotice, Message: "consider renaming"},
+		{File: "go.mod", Line: 1, Severity: SeverityWarning, Message: "old go version", Rule: "gomod"},
+	}, res)
+
+	_, err = Parse([]byte("not json\n"))
+	require.Error(t, err)
+}
+
+func TestRelativeFile(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	require.Equal(t, "pkg/main.go", relativeFile("./pkg/../pkg/main.go"))
+	require.Equal(t, "pkg/main.go", relativeFile(filepath.Join(wd, "pkg", "main.go")))
+	// a directory starting with .. is still under the working directory
+	require.Equal(t, "..foo/bar.go", relativeFile(filepath.Join(wd, "..foo", "bar.go")))
+	outside := filepath.Join(filepath.Dir(wd), "other", "main.go")
+	require.Equal(t, outside, relativeFile(outside))
+	require.Equal(t, filepath.Dir(wd), relativeFile(filepath.Dir(wd)))
+}
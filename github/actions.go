@@ -0,0 +1,88 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// annotation levels supported by workflow commands
+const (
+	AnnotationError   = "error"
+	AnnotationWarning = "warning"
+	AnnotationNotice  = "notice"
+)
+
+// Annotation is shown in the github actions ui and on the PR diff when File is set
+type Annotation struct {
+	Level   string
+	File    string
+	Line    int
+	Title   string
+	Message string
+}
+
+// WriteAnnotations prints annotations as workflow commands (::error file=...,line=...::message)
+func WriteAnnotations(w io.Writer, annotations []Annotation) error {
+	for _, a := range annotations {
+		level := a.Level
+		if level == "" {
+			level = AnnotationError
+		}
+		var props []string
+		if a.File != "" {
+			props = append(props, "file="+escapeWorkflowProperty(a.File))
+			if a.Line > 0 {
+				props = append(props, fmt.Sprintf("line=%d", a.Line))
+			}
+		}
+		if a.Title != "" {
+			props = append(props, "title="+escapeWorkflowProperty(a.Title))
+		}
+		command := "::" + level
+		if len(props) > 0 {
+			command += " " + strings.Join(props, ",")
+		}
+		_, err := fmt.Fprintf(w, "%s::%s\n", command, escapeWorkflowData(a.Message))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendStepSummary appends markdown to the job summary ($GITHUB_STEP_SUMMARY)
+func AppendStepSummary(markdown string) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return errors.New("GITHUB_STEP_SUMMARY is not set")
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to open step summary: %w", err)
+	}
+	defer file.Close()
+
+	if !strings.HasSuffix(markdown, "\n") {
+		markdown += "\n"
+	}
+	_, err = file.WriteString(markdown)
+	if err != nil {
+		return fmt.Errorf("unable to write step summary: %w", err)
+	}
+	return nil
+}
+
+func escapeWorkflowData(val string) string {
+	val = strings.ReplaceAll(val, "%", "%25")
+	val = strings.ReplaceAll(val, "\r", "%0D")
+	return strings.ReplaceAll(val, "\n", "%0A")
+}
+
+func escapeWorkflowProperty(val string) string {
+	val = escapeWorkflowData(val)
+	val = strings.ReplaceAll(val, ":", "%3A")
+	return strings.ReplaceAll(val, ",", "%2C")
+}
@@ -0,0 +1,18 @@
+package github
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAnnotations(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := WriteAnnotations(buf, []Annotation{
+		{Level: AnnotationWarning, File: "a_test.go", Line: 4, Title: "pkg.TestA", Message: "line 1\nline 2: 100%"},
+		{Message: "no file"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "::warning file=a_test.go,line=4,title=pkg.TestA::line 1%0Aline 2: 100%25\n::error::no file\n", buf.String())
+}
@@ -0,0 +1,129 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
+	"github.com/google/go-github/v45/github"
+)
+
+// conclusions of a completed check run
+const (
+	CheckConclusionSuccess = "success"
+	CheckConclusionFailure = "failure"
+	CheckConclusionNeutral = "neutral"
+)
+
+// github accepts at most 50 annotations per create or update request
+const maxCheckRunAnnotations = 50
+
+// CheckRun is a completed check run. Annotations without a File are left out (check run annotations need a file).
+type CheckRun struct {
+	Name       string
+	Conclusion string
+	Title      string
+	// Summary and Text are markdown, truncated to github's size limit
+	Summary     string
+	Text        string
+	DetailsURL  string
+	Annotations []Annotation
+}
+
+var checkRunAnnotationLevels = map[string]string{
+	AnnotationError:   "failure",
+	AnnotationWarning: "warning",
+	AnnotationNotice:  "notice",
+}
+
+// PublishCheckRun creates a completed check run on sha. Annotations are added in batches of 50,
+// a dry run only prints the first batch as there's no check run to update.
+//
+// github.repository => repo (alexgartner-bc/my-repo)
+func PublishCheckRun(ctx context.Context, repo string, sha string, run *CheckRun) error {
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	var annotations []*github.CheckRunAnnotation
+	for _, a := range run.Annotations {
+		if a.File == "" {
+			continue
+		}
+		level, ok := checkRunAnnotationLevels[a.Level]
+		if !ok {
+			level = checkRunAnnotationLevels[AnnotationError]
+		}
+		line := a.Line
+		if line < 1 {
+			line = 1
+		}
+		annotation := &github.CheckRunAnnotation{
+			Path:            github.String(a.File),
+			StartLine:       github.Int(line),
+			EndLine:         github.Int(line),
+			AnnotationLevel: github.String(level),
+			Message:         github.String(a.Message),
+		}
+		if a.Title != "" {
+			annotation.Title = github.String(a.Title)
+		}
+		annotations = append(annotations, annotation)
+	}
+
+	output := func(batch []*github.CheckRunAnnotation) *github.CheckRunOutput {
+		out := &github.CheckRunOutput{
+			Title:       github.String(run.Title),
+			Summary:     github.String(TruncateMarkdown(run.Summary, MaxBodyLength-1, truncatedCommentNote)),
+			Annotations: batch,
+		}
+		if run.Text != "" {
+			out.Text = github.String(TruncateMarkdown(run.Text, MaxBodyLength-1, truncatedCommentNote))
+		}
+		return out
+	}
+
+	first := annotations
+	if len(first) > maxCheckRunAnnotations {
+		first = first[:maxCheckRunAnnotations]
+	}
+	opts := github.CreateCheckRunOptions{
+		Name:       run.Name,
+		HeadSHA:    sha,
+		Status:     github.String("completed"),
+		Conclusion: github.String(run.Conclusion),
+		Output:     output(first),
+	}
+	if run.DetailsURL != "" {
+		opts.DetailsURL = github.String(run.DetailsURL)
+	}
+	checkRun, _, err := client.Checks.CreateCheckRun(ctx, owner, name, opts)
+	if err != nil {
+		return fmt.Errorf("unable to create check run: %w", err)
+	}
+	if dryrun.Enabled && len(annotations) > len(first) {
+		dryrun.Printf("%d more annotations would be added to the check run\n", len(annotations)-len(first))
+		return nil
+	}
+
+	for start := len(first); start < len(annotations); start += maxCheckRunAnnotations {
+		end := start + maxCheckRunAnnotations
+		if end > len(annotations) {
+			end = len(annotations)
+		}
+		_, _, err = client.Checks.UpdateCheckRun(ctx, owner, name, checkRun.GetID(), github.UpdateCheckRunOptions{
+			Name:   run.Name,
+			Output: output(annotations[start:end]),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to add annotations to check run: %w", err)
+		}
+	}
+	return nil
+}
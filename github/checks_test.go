@@ -0,0 +1,82 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishCheckRunBatches(t *testing.T) {
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			Conclusion string `json:"conclusion"`
+			Output     struct {
+				Annotations []json.RawMessage `json:"annotations"`
+			} `json:"output"`
+		}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/owner/repo/check-runs":
+			require.Equal(t, CheckConclusionFailure, req.Conclusion)
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v3/repos/owner/repo/check-runs/7":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		batches = append(batches, len(req.Output.Annotations))
+		fmt.Fprint(w, `{"id": 7}`)
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL+"/api/v3")
+	t.Setenv("GITHUB_TOKEN", "fake")
+	t.Setenv("GITHUB_APP_ID", "")
+
+	run := &CheckRun{
+		Name:       "sarif",
+		Conclusion: CheckConclusionFailure,
+		Title:      "error 120",
+		Summary:    "summary",
+		// no file, left out
+		Annotations: []Annotation{{Message: "no file"}},
+	}
+	for i := 0; i < 120; i++ {
+		run.Annotations = append(run.Annotations, Annotation{Level: AnnotationError, File: "main.go", Line: i, Message: "bad"})
+	}
+	err := PublishCheckRun(context.Background(), "owner/repo", "abc", run)
+	require.NoError(t, err)
+	require.Equal(t, []int{50, 50, 20}, batches)
+}
+
+func TestPublishCheckRunDryRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL+"/api/v3")
+	t.Setenv("GITHUB_TOKEN", "fake")
+	t.Setenv("GITHUB_APP_ID", "")
+	var out bytes.Buffer
+	dryrun.Enabled, dryrun.Output = true, &out
+	t.Cleanup(func() {
+		dryrun.Enabled, dryrun.Output = false, os.Stderr
+	})
+
+	run := &CheckRun{Name: "sarif", Conclusion: CheckConclusionFailure, Title: "error 70", Summary: "summary"}
+	for i := 0; i < 70; i++ {
+		run.Annotations = append(run.Annotations, Annotation{Level: AnnotationError, File: "main.go", Line: i, Message: "bad"})
+	}
+	err := PublishCheckRun(context.Background(), "owner/repo", "abc", run)
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "dry-run: POST ")
+	require.NotContains(t, out.String(), "check-runs/0")
+	require.Contains(t, out.String(), "dry-run: 20 more annotations would be added to the check run\n")
+}
@@ -2,18 +2,236 @@ package github
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/go-github/v45/github"
 	"golang.org/x/oauth2"
 )
 
-func getDefaultClient() *github.Client {
+const defaultAPIURL = "https://api.github.com/"
+
+var (
+	clientsMu sync.Mutex
+	// clients are cached per api url and repo so app installation tokens are reused between calls
+	clients = make(map[string]*github.Client)
+)
+
+// splitRepo returns the owner and name of repo (owner/name)
+func splitRepo(repo string) (string, string, error) {
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("repo must be owner/name, got %q", repo)
+	}
+	return parts[0], parts[1], nil
+}
+
+// getDefaultClient returns a client for repo configured from the environment
+//
+// GITHUB_TOKEN => static token
+// GITHUB_APP_ID + GITHUB_APP_PRIVATE_KEY (or GITHUB_APP_PRIVATE_KEY_PATH) => authenticate as a github app.
+// GITHUB_APP_INSTALLATION_ID is optional, the installation for repo is looked up if it isn't set.
+// GITHUB_API_URL => api url for github enterprise server (https://github.example.com/api/v3)
+func getDefaultClient(repo string) (*github.Client, error) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	cacheKey := fmt.Sprintf("%s %s %t", os.Getenv("GITHUB_API_URL"), repo, dryrun.Enabled)
+	if client, ok := clients[cacheKey]; ok {
+		return client, nil
+	}
+
+	var ts oauth2.TokenSource
+	if os.Getenv("GITHUB_APP_ID") != "" {
+		appTs, err := newAppInstallationTokenSource(repo)
+		if err != nil {
+			return nil, fmt.Errorf("unable to authenticate as github app: %w", err)
+		}
+		ts = appTs
+	} else {
+		ts = oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: os.Getenv("GITHUB_TOKEN")},
+		)
+	}
+	tc := oauth2.NewClient(context.Background(), ts)
+	if dryrun.Enabled && os.Getenv("GITHUB_APP_ID") == "" && os.Getenv("GITHUB_TOKEN") == "" {
+		// reads of public repos work without a token
+		tc = &http.Client{}
+	}
+	tc.Transport = newRetryTransport(tc.Transport)
+	if dryrun.Enabled {
+		tc.Transport = &dryrun.Transport{
+			Base:     tc.Transport,
+			IsRead:   isGithubRead,
+			Response: githubDryRunResponse,
+		}
+	}
+
+	client, err := newClient(tc)
+	if err != nil {
+		return nil, err
+	}
+	clients[cacheKey] = client
+	return client, nil
+}
+
+// isGithubRead is true for requests which don't change anything, including graphql queries
+func isGithubRead(req *http.Request, body []byte) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/graphql") {
+		return false
+	}
+	gqlReq := &graphQLRequest{}
+	if json.Unmarshal(body, gqlReq) != nil {
+		return false
+	}
+	return !strings.HasPrefix(strings.TrimSpace(gqlReq.Query), "mutation")
+}
+
+func githubDryRunResponse(req *http.Request) string {
+	if strings.HasSuffix(req.URL.Path, "/graphql") {
+		return `{"data": {}}`
+	}
+	return "{}"
+}
+
+// newClient returns a client for github.com or GITHUB_API_URL
+func newClient(httpClient *http.Client) (*github.Client, error) {
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" || strings.TrimSuffix(apiURL, "/")+"/" == defaultAPIURL {
+		return github.NewClient(httpClient), nil
+	}
+
+	uploadURL := os.Getenv("GITHUB_UPLOAD_URL")
+	if uploadURL == "" {
+		// https://github.example.com/api/v3 => https://github.example.com/api/uploads
+		uploadURL = apiURL
+		u, err := url.Parse(apiURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_API_URL: %w", err)
+		}
+		if path := strings.TrimSuffix(u.Path, "/"); strings.HasSuffix(path, "/api/v3") {
+			u.Path = strings.TrimSuffix(path, "/v3") + "/uploads/"
+			uploadURL = u.String()
+		}
+	}
+	client, err := github.NewEnterpriseClient(apiURL, uploadURL, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_API_URL: %w", err)
+	}
+	return client, nil
+}
+
+// appJWTTokenSource signs the short lived jwt used to authenticate as the github app itself
+type appJWTTokenSource struct {
+	appID string
+	key   interface{}
+}
+
+func (s *appJWTTokenSource) Token() (*oauth2.Token, error) {
+	now := time.Now()
+	// github allows at most 10 minutes and recommends backdating for clock drift
+	expiry := now.Add(9 * time.Minute)
+	claims := jwt.RegisteredClaims{
+		IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(expiry),
+		Issuer:    s.appID,
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(s.key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign jwt: %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: signed,
+		TokenType:   "Bearer",
+		Expiry:      expiry,
+	}, nil
+}
+
+// appInstallationTokenSource exchanges the app jwt for an installation token
+type appInstallationTokenSource struct {
+	appClient      *github.Client
+	installationID int64
+	repo           string
+}
+
+func newAppInstallationTokenSource(repo string) (oauth2.TokenSource, error) {
+	appID := os.Getenv("GITHUB_APP_ID")
+
+	pemKey := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+	if keyPath := os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH"); keyPath != "" {
+		var err error
+		pemKey, err = os.ReadFile(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read private key: %w", err)
+		}
+	}
+	if len(pemKey) == 0 {
+		return nil, fmt.Errorf("GITHUB_APP_PRIVATE_KEY or GITHUB_APP_PRIVATE_KEY_PATH must be set")
+	}
+	// keys passed through env vars often have escaped newlines
+	pemKey = []byte(strings.ReplaceAll(string(pemKey), `\n`, "\n"))
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(pemKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key: %w", err)
+	}
+
+	var installationID int64
+	if id := os.Getenv("GITHUB_APP_INSTALLATION_ID"); id != "" {
+		installationID, err = strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_APP_INSTALLATION_ID: %w", err)
+		}
+	}
+
+	jwtTs := oauth2.ReuseTokenSource(nil, &appJWTTokenSource{appID: appID, key: key})
+	jwtClient := oauth2.NewClient(context.Background(), jwtTs)
+	jwtClient.Transport = newRetryTransport(jwtClient.Transport)
+	appClient, err := newClient(jwtClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return oauth2.ReuseTokenSource(nil, &appInstallationTokenSource{
+		appClient:      appClient,
+		installationID: installationID,
+		repo:           repo,
+	}), nil
+}
+
+func (s *appInstallationTokenSource) Token() (*oauth2.Token, error) {
 	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: os.Getenv("GITHUB_TOKEN")},
-	)
-	tc := oauth2.NewClient(ctx, ts)
 
-	return github.NewClient(tc)
+	if s.installationID == 0 {
+		owner, name, err := splitRepo(s.repo)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find the app installation: %w", err)
+		}
+		installation, _, err := s.appClient.Apps.FindRepositoryInstallation(ctx, owner, name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find app installation for %s: %w", s.repo, err)
+		}
+		s.installationID = installation.GetID()
+	}
+
+	token, _, err := s.appClient.Apps.CreateInstallationToken(ctx, s.installationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create installation token: %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: token.GetToken(),
+		TokenType:   "token",
+		Expiry:      token.GetExpiresAt(),
+	}, nil
 }
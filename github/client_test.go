@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDefaultClientApp(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/owner/repo/installation", func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		claims := &jwt.RegisteredClaims{}
+		_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "1234", claims.Issuer)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 42})
+	})
+	mux.HandleFunc("/api/v3/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      "installation-token",
+			"expires_at": time.Now().Add(time.Hour),
+		})
+	})
+	var statusAuth string
+	mux.HandleFunc("/api/v3/repos/owner/repo/statuses/abc", func(w http.ResponseWriter, r *http.Request) {
+		statusAuth = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Setenv("GITHUB_API_URL", srv.URL+"/api/v3")
+	t.Setenv("GITHUB_APP_ID", "1234")
+	t.Setenv("GITHUB_APP_PRIVATE_KEY", string(pemKey))
+
+	err = SetCommitStatus(context.Background(), "owner/repo", "abc", StatusSuccess, "test", "", "")
+	require.NoError(t, err)
+	require.Equal(t, "token installation-token", statusAuth)
+}
+
+func TestSplitRepo(t *testing.T) {
+	owner, name, err := splitRepo("owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, "owner", owner)
+	require.Equal(t, "repo", name)
+
+	for _, repo := range []string{"", "repo", "owner/", "/repo", "group/sub/project"} {
+		_, _, err = splitRepo(repo)
+		require.ErrorContains(t, err, "repo must be owner/name", repo)
+	}
+}
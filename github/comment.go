@@ -3,88 +3,498 @@ package github
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v45/github"
 )
 
+// StickyKeyText is the hidden marker which tags a sticky comment
+func StickyKeyText(stickyKey string) string {
+	return stickyKeyText(stickyKey)
+}
+
+func stickyKeyText(stickyKey string) string {
+	return fmt.Sprintf("\n<!-- key %s -->\n", stickyKey)
+}
+
+// partKeyText tags the comments a long sticky comment was split into. The first part uses the plain key.
+func partKeyText(stickyKey string, part int) string {
+	if part == 0 {
+		return stickyKeyText(stickyKey)
+	}
+	return fmt.Sprintf("\n<!-- key %s part %d -->\n", stickyKey, part+1)
+}
+
+// hasStickyKey is true for any part of the sticky comment
+func hasStickyKey(body string, stickyKey string) bool {
+	return strings.Contains(body, stickyKeyText(stickyKey)) ||
+		strings.Contains(body, fmt.Sprintf("\n<!-- key %s part ", stickyKey))
+}
+
+// minimizedBody removes the key from a comment so it isn't found (and updated) again
+func minimizedBody(body string, stickyKey string) string {
+	return strings.ReplaceAll(body, fmt.Sprintf("\n<!-- key %s ", stickyKey), fmt.Sprintf("\n<!-- minimized key %s ", stickyKey))
+}
+
+const truncatedCommentNote = "\n_… %d more lines truncated (github comment size limit)_\n"
+
+// commentParts returns the bodies (with key) to post for text. Text over the size limit is truncated, or split if split is set.
+func commentParts(text string, stickyKey string, split bool) []string {
+	keyText := stickyKeyText(stickyKey)
+	if len(text)+len(keyText) <= MaxBodyLength {
+		return []string{text + keyText}
+	}
+	if !split {
+		return []string{TruncateMarkdown(text, MaxBodyLength-len(keyText), truncatedCommentNote) + keyText}
+	}
+
+	// room for the part header and key
+	overhead := len(partKeyText(stickyKey, 1000)) + 64
+	chunks := splitMarkdown(text, MaxBodyLength-overhead)
+	var parts []string
+	for i, chunk := range chunks {
+		if i > 0 {
+			chunk = fmt.Sprintf("_(continued, part %d of %d)_\n\n", i+1, len(chunks)) + chunk
+		}
+		parts = append(parts, chunk+partKeyText(stickyKey, i))
+	}
+	return parts
+}
+
+// CommentOptions changes how a sticky comment is posted
+type CommentOptions struct {
+	// MinimizePrevious hides the previous comment with the same key as outdated and posts a new one instead of editing it
+	MinimizePrevious bool
+	// Split posts text over MaxBodyLength as numbered comments sharing the key instead of truncating it
+	Split bool
+	// History keeps the previous text of the comment in a collapsed "Previous runs" section below the new text
+	History bool
+	// HistoryCount is the most previous runs kept (default 5)
+	HistoryCount int
+	// HistorySize is the most bytes of previous runs kept (default: as much as fits in the comment)
+	HistorySize int
+	// SHA is the commit the text is about, shown next to previous runs
+	SHA string
+}
+
+// historyText returns text with the history kept from the newest existing comment's body
+func (opts *CommentOptions) historyText(text string, stickyKey string, previousBody string, updatedAt time.Time) string {
+	previousBody = strings.ReplaceAll(previousBody, "\r\n", "\n")
+	previousBody = strings.Replace(previousBody, stickyKeyText(stickyKey), "", 1)
+	return withHistory(text, opts.SHA, time.Now(), previousBody, updatedAt, opts.HistoryCount, opts.HistorySize, stickyKey)
+}
+
 // CommentOnIssue posts a comment on an issue or PR
 //
 // github.repository => repo (alexgartner-bc/my-repo)
 // github.event.issue.number => number
 func CommentOnIssue(ctx context.Context, repo string, number int, text string, stickyKey string) error {
-	client := getDefaultClient()
+	return CommentOnIssueWithOptions(ctx, repo, number, text, stickyKey, nil)
+}
 
-	repoParts := strings.Split(repo, "/")
+// CommentOnIssueWithOptions is CommentOnIssue with options (can be nil)
+func CommentOnIssueWithOptions(ctx context.Context, repo string, number int, text string, stickyKey string, opts *CommentOptions) error {
+	if opts == nil {
+		opts = &CommentOptions{}
+	}
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
 
-	stickyKeyText := fmt.Sprintf("\n<!-- key %s -->\n", stickyKey)
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
 
-	text += stickyKeyText
+	// try to find existing comments
+	existingComments, err := listIssueComments(ctx, client, owner, name, number, stickyKey)
+	if err != nil {
+		return err
+	}
 
-	commentReq := &github.IssueComment{
-		Body: &text,
+	if opts.History {
+		previousBody, updatedAt := "", time.Now()
+		if previous := filterIssueComments(existingComments, partKeyText(stickyKey, 0)); len(previous) > 0 {
+			previousBody, updatedAt = previous[0].GetBody(), previous[0].GetUpdatedAt()
+		}
+		text = opts.historyText(text, stickyKey, previousBody, updatedAt)
 	}
 
-	commentListOptions := &github.IssueListCommentsOptions{
-		ListOptions: github.ListOptions{
-			PerPage: 100,
-		},
+	parts := commentParts(text, stickyKey, opts.Split)
+	for i, part := range parts {
+		err = upsertIssueComment(ctx, client, owner, name, number, part, stickyKey,
+			filterIssueComments(existingComments, partKeyText(stickyKey, i)), opts)
+		if err != nil {
+			return err
+		}
 	}
-	// try to find existing comment
-	existingComments, _, err := client.Issues.ListComments(ctx, repoParts[0], repoParts[1], number, commentListOptions)
-	if err != nil {
-		return fmt.Errorf("unable to list comments: %w", err)
+
+	// clean up parts left over from a longer previous comment
+	for i := len(parts); ; i++ {
+		leftover := filterIssueComments(existingComments, partKeyText(stickyKey, i))
+		if len(leftover) == 0 {
+			break
+		}
+		for _, comment := range leftover {
+			_, err = client.Issues.DeleteComment(ctx, owner, name, comment.GetID())
+			if err != nil {
+				return fmt.Errorf("unable to delete comment: %w", err)
+			}
+		}
 	}
-	for _, comment := range existingComments {
-		if strings.Contains(*comment.Body, stickyKeyText) {
-			// update existing comment
-			_, _, err = client.Issues.EditComment(ctx, repoParts[0], repoParts[1], *comment.ID, commentReq)
+	return nil
+}
+
+// upsertIssueComment updates the newest existing comment (deleting duplicates) or creates a new one
+func upsertIssueComment(ctx context.Context, client *github.Client, owner string, name string, number int, body string, stickyKey string, existingComments []*github.IssueComment, opts *CommentOptions) error {
+	commentReq := &github.IssueComment{
+		Body: &body,
+	}
+
+	if opts.MinimizePrevious {
+		for _, comment := range existingComments {
+			err := minimizeIssueComment(ctx, client, owner, name, comment, stickyKey)
+			if err != nil {
+				return err
+			}
+		}
+		existingComments = nil
+	}
+	if len(existingComments) > 0 {
+		// update the newest comment and clean up any duplicates
+		_, _, err := client.Issues.EditComment(ctx, owner, name, existingComments[0].GetID(), commentReq)
+		if err != nil {
+			return fmt.Errorf("unable to edit comment: %w", err)
+		}
+		for _, comment := range existingComments[1:] {
+			_, err = client.Issues.DeleteComment(ctx, owner, name, comment.GetID())
 			if err != nil {
-				return fmt.Errorf("unable to edit comment: %w", err)
+				return fmt.Errorf("unable to delete duplicate comment: %w", err)
 			}
-			return nil
 		}
+		return nil
 	}
 
-	_, _, err = client.Issues.CreateComment(ctx, repoParts[0], repoParts[1], number, commentReq)
+	_, _, err := client.Issues.CreateComment(ctx, owner, name, number, commentReq)
 	if err != nil {
 		return fmt.Errorf("unable to create comment: %w", err)
 	}
 	return nil
 }
 
-func CommentOnCommit(ctx context.Context, repo string, sha string, text string, stickyKey string) error {
-	client := getDefaultClient()
+// DeleteIssueComment deletes the comment(s) on an issue or PR tagged with stickyKey. It is not an error if there are none.
+func DeleteIssueComment(ctx context.Context, repo string, number int, stickyKey string) error {
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
 
-	repoParts := strings.Split(repo, "/")
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
 
-	stickyKeyText := fmt.Sprintf("\n<!-- key %s -->\n", stickyKey)
+	existingComments, err := listIssueComments(ctx, client, owner, name, number, stickyKey)
+	if err != nil {
+		return err
+	}
+	for _, comment := range existingComments {
+		_, err = client.Issues.DeleteComment(ctx, owner, name, comment.GetID())
+		if err != nil {
+			return fmt.Errorf("unable to delete comment: %w", err)
+		}
+	}
+	return nil
+}
 
-	text += stickyKeyText
+// MinimizeIssueComment hides the comment(s) on an issue or PR tagged with stickyKey as outdated. It is not an error if there are none.
+// The key is removed from minimized comments so the next comment with the same key is posted fresh.
+func MinimizeIssueComment(ctx context.Context, repo string, number int, stickyKey string) error {
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
 
-	commentReq := &github.RepositoryComment{
-		Body: &text,
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
 	}
 
-	// try to find existing comment
-	existingComments, _, err := client.Repositories.ListCommitComments(ctx, repoParts[0], repoParts[1], sha, nil)
+	existingComments, err := listIssueComments(ctx, client, owner, name, number, stickyKey)
 	if err != nil {
-		return fmt.Errorf("unable to list comments: %w", err)
+		return err
 	}
 	for _, comment := range existingComments {
-		if strings.Contains(*comment.Body, stickyKeyText) {
-			// update existing comment
-			_, _, err = client.Repositories.UpdateComment(ctx, repoParts[0], repoParts[1], *comment.ID, commentReq)
+		err = minimizeIssueComment(ctx, client, owner, name, comment, stickyKey)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// minimizeIssueComment hides comment then drops its key. The key is only removed once the comment is hidden,
+// a failed minimize leaves the comment visible and findable by the next run.
+func minimizeIssueComment(ctx context.Context, client *github.Client, owner string, name string, comment *github.IssueComment, stickyKey string) error {
+	err := minimizeComment(ctx, client, comment.GetNodeID())
+	if err != nil {
+		return err
+	}
+	body := minimizedBody(comment.GetBody(), stickyKey)
+	_, _, err = client.Issues.EditComment(ctx, owner, name, comment.GetID(), &github.IssueComment{Body: &body})
+	if err != nil {
+		return fmt.Errorf("unable to edit comment: %w", err)
+	}
+	return nil
+}
+
+// CommentOnCommit posts a comment on a commit
+//
+// github.repository => repo (alexgartner-bc/my-repo)
+// github.sha => sha
+func CommentOnCommit(ctx context.Context, repo string, sha string, text string, stickyKey string) error {
+	return CommentOnCommitWithOptions(ctx, repo, sha, text, stickyKey, nil)
+}
+
+// CommentOnCommitWithOptions is CommentOnCommit with options (can be nil)
+func CommentOnCommitWithOptions(ctx context.Context, repo string, sha string, text string, stickyKey string, opts *CommentOptions) error {
+	if opts == nil {
+		opts = &CommentOptions{}
+	}
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	// try to find existing comments
+	existingComments, err := listCommitComments(ctx, client, owner, name, sha, stickyKey)
+	if err != nil {
+		return err
+	}
+
+	if opts.History {
+		previousBody, updatedAt := "", time.Now()
+		if previous := filterCommitComments(existingComments, partKeyText(stickyKey, 0)); len(previous) > 0 {
+			previousBody, updatedAt = previous[0].GetBody(), previous[0].GetUpdatedAt()
+		}
+		text = opts.historyText(text, stickyKey, previousBody, updatedAt)
+	}
+
+	parts := commentParts(text, stickyKey, opts.Split)
+	for i, part := range parts {
+		err = upsertCommitComment(ctx, client, owner, name, sha, part, stickyKey,
+			filterCommitComments(existingComments, partKeyText(stickyKey, i)), opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	// clean up parts left over from a longer previous comment
+	for i := len(parts); ; i++ {
+		leftover := filterCommitComments(existingComments, partKeyText(stickyKey, i))
+		if len(leftover) == 0 {
+			break
+		}
+		for _, comment := range leftover {
+			_, err = client.Repositories.DeleteComment(ctx, owner, name, comment.GetID())
+			if err != nil {
+				return fmt.Errorf("unable to delete comment: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// upsertCommitComment updates the newest existing comment (deleting duplicates) or creates a new one
+func upsertCommitComment(ctx context.Context, client *github.Client, owner string, name string, sha string, body string, stickyKey string, existingComments []*github.RepositoryComment, opts *CommentOptions) error {
+	commentReq := &github.RepositoryComment{
+		Body: &body,
+	}
+
+	if opts.MinimizePrevious {
+		for _, comment := range existingComments {
+			err := minimizeCommitComment(ctx, client, owner, name, comment, stickyKey)
+			if err != nil {
+				return err
+			}
+		}
+		existingComments = nil
+	}
+	if len(existingComments) > 0 {
+		// update the newest comment and clean up any duplicates
+		_, _, err := client.Repositories.UpdateComment(ctx, owner, name, existingComments[0].GetID(), commentReq)
+		if err != nil {
+			return fmt.Errorf("unable to edit comment: %w", err)
+		}
+		for _, comment := range existingComments[1:] {
+			_, err = client.Repositories.DeleteComment(ctx, owner, name, comment.GetID())
 			if err != nil {
-				return fmt.Errorf("unable to edit comment: %w", err)
+				return fmt.Errorf("unable to delete duplicate comment: %w", err)
 			}
-			return nil
 		}
+		return nil
 	}
 
-	_, _, err = client.Repositories.CreateComment(ctx, repoParts[0], repoParts[1], sha, commentReq)
+	_, _, err := client.Repositories.CreateComment(ctx, owner, name, sha, commentReq)
 	if err != nil {
 		return fmt.Errorf("unable to create comment: %w", err)
 	}
 	return nil
 }
+
+// DeleteCommitComment deletes the comment(s) on a commit tagged with stickyKey. It is not an error if there are none.
+func DeleteCommitComment(ctx context.Context, repo string, sha string, stickyKey string) error {
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	existingComments, err := listCommitComments(ctx, client, owner, name, sha, stickyKey)
+	if err != nil {
+		return err
+	}
+	for _, comment := range existingComments {
+		_, err = client.Repositories.DeleteComment(ctx, owner, name, comment.GetID())
+		if err != nil {
+			return fmt.Errorf("unable to delete comment: %w", err)
+		}
+	}
+	return nil
+}
+
+// MinimizeCommitComment hides the comment(s) on a commit tagged with stickyKey as outdated. It is not an error if there are none.
+// The key is removed from minimized comments so the next comment with the same key is posted fresh.
+func MinimizeCommitComment(ctx context.Context, repo string, sha string, stickyKey string) error {
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	existingComments, err := listCommitComments(ctx, client, owner, name, sha, stickyKey)
+	if err != nil {
+		return err
+	}
+	for _, comment := range existingComments {
+		err = minimizeCommitComment(ctx, client, owner, name, comment, stickyKey)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// minimizeCommitComment is minimizeIssueComment for commit comments
+func minimizeCommitComment(ctx context.Context, client *github.Client, owner string, name string, comment *github.RepositoryComment, stickyKey string) error {
+	err := minimizeComment(ctx, client, comment.GetNodeID())
+	if err != nil {
+		return err
+	}
+	body := minimizedBody(comment.GetBody(), stickyKey)
+	_, _, err = client.Repositories.UpdateComment(ctx, owner, name, comment.GetID(), &github.RepositoryComment{Body: &body})
+	if err != nil {
+		return fmt.Errorf("unable to edit comment: %w", err)
+	}
+	return nil
+}
+
+// listIssueComments walks every page of comments on an issue and returns the ones tagged with stickyKey (any part), newest first
+func listIssueComments(ctx context.Context, client *github.Client, owner string, name string, number int, stickyKey string) ([]*github.IssueComment, error) {
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	var res []*github.IssueComment
+	for {
+		comments, resp, err := client.Issues.ListComments(ctx, owner, name, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list comments: %w", err)
+		}
+		for _, comment := range comments {
+			if hasStickyKey(comment.GetBody(), stickyKey) {
+				res = append(res, comment)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return newerThan(res[i].GetCreatedAt(), res[i].GetID(), res[j].GetCreatedAt(), res[j].GetID())
+	})
+	return res, nil
+}
+
+func filterIssueComments(comments []*github.IssueComment, keyText string) []*github.IssueComment {
+	var res []*github.IssueComment
+	for _, comment := range comments {
+		if strings.Contains(comment.GetBody(), keyText) {
+			res = append(res, comment)
+		}
+	}
+	return res
+}
+
+// listCommitComments walks every page of comments on a commit and returns the ones tagged with stickyKey (any part), newest first
+func listCommitComments(ctx context.Context, client *github.Client, owner string, name string, sha string, stickyKey string) ([]*github.RepositoryComment, error) {
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+	var res []*github.RepositoryComment
+	for {
+		comments, resp, err := client.Repositories.ListCommitComments(ctx, owner, name, sha, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list comments: %w", err)
+		}
+		for _, comment := range comments {
+			if hasStickyKey(comment.GetBody(), stickyKey) {
+				res = append(res, comment)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return newerThan(res[i].GetCreatedAt(), res[i].GetID(), res[j].GetCreatedAt(), res[j].GetID())
+	})
+	return res, nil
+}
+
+func filterCommitComments(comments []*github.RepositoryComment, keyText string) []*github.RepositoryComment {
+	var res []*github.RepositoryComment
+	for _, comment := range comments {
+		if strings.Contains(comment.GetBody(), keyText) {
+			res = append(res, comment)
+		}
+	}
+	return res
+}
+
+// newerThan orders comments by creation time, falling back to the (monotonic) id
+func newerThan(aCreated time.Time, aID int64, bCreated time.Time, bID int64) bool {
+	if !aCreated.Equal(bCreated) {
+		return aCreated.After(bCreated)
+	}
+	return aID > bID
+}
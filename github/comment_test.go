@@ -0,0 +1,54 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinimizeIssueComment(t *testing.T) {
+	fake := newFakeGithub(t)
+	id := fake.addComment("preview" + stickyKeyText("key"))
+
+	// a failed minimize leaves the comment findable by the next run
+	fake.failMinimize = true
+	require.Error(t, MinimizeIssueComment(context.Background(), "owner/repo", 1, "key"))
+	require.Equal(t, "preview"+stickyKeyText("key"), fake.comments[id])
+	require.False(t, fake.minimized[id])
+
+	fake.failMinimize = false
+	require.NoError(t, MinimizeIssueComment(context.Background(), "owner/repo", 1, "key"))
+	require.Equal(t, "preview\n<!-- minimized key key -->\n", fake.comments[id])
+	require.True(t, fake.minimized[id])
+}
+
+func TestCommentOnIssueDuplicatesAcrossPages(t *testing.T) {
+	fake := newFakeGithub(t)
+	var duplicates []int64
+	for i := 0; i < 250; i++ {
+		switch i {
+		case 10, 120, 230:
+			// sticky comments left by earlier runs on every page
+			duplicates = append(duplicates, fake.addComment(fmt.Sprintf("run %d", i)+stickyKeyText("key")))
+		case 150:
+			fake.addComment("other" + stickyKeyText("other-key"))
+		default:
+			fake.addComment(fmt.Sprintf("comment %d", i))
+		}
+	}
+
+	require.NoError(t, CommentOnIssue(context.Background(), "owner/repo", 1, "new run", "key"))
+	// the newest is updated, the older duplicates are cleaned up
+	require.Len(t, fake.comments, 248)
+	require.Equal(t, "new run"+stickyKeyText("key"), fake.comments[duplicates[2]])
+	require.NotContains(t, fake.comments, duplicates[0])
+	require.NotContains(t, fake.comments, duplicates[1])
+
+	fake.addComment("again" + stickyKeyText("key"))
+	require.NoError(t, DeleteIssueComment(context.Background(), "owner/repo", 1, "key"))
+	require.Len(t, fake.comments, 247)
+	require.NotContains(t, fake.comments, duplicates[2])
+	require.Equal(t, "other"+stickyKeyText("other-key"), fake.comments[151])
+}
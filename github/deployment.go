@@ -0,0 +1,112 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// deployment status states accepted by github
+const (
+	DeploymentStateQueued     = "queued"
+	DeploymentStatePending    = "pending"
+	DeploymentStateInProgress = "in_progress"
+	DeploymentStateSuccess    = "success"
+	DeploymentStateFailure    = "failure"
+	DeploymentStateError      = "error"
+	DeploymentStateInactive   = "inactive"
+)
+
+// Deployment describes a deployment to create
+type Deployment struct {
+	// Ref is the sha, branch or tag being deployed
+	Ref         string
+	Environment string
+	Description string
+	// Transient environments are destroyed at some point (preview environments)
+	Transient  bool
+	Production bool
+}
+
+// CreateDeployment creates a deployment of a ref to an environment and returns its id.
+// Commit status checks aren't required to pass and the ref isn't merged with the default branch, ci already decided to deploy.
+//
+// github.repository => repo (alexgartner-bc/my-repo)
+func CreateDeployment(ctx context.Context, repo string, deployment *Deployment) (int64, error) {
+	if deployment.Ref == "" {
+		return 0, fmt.Errorf("deployment ref must be set")
+	}
+	if deployment.Environment == "" {
+		return 0, fmt.Errorf("deployment environment must be set")
+	}
+
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return 0, err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return 0, err
+	}
+
+	req := &github.DeploymentRequest{
+		Ref:                   &deployment.Ref,
+		Environment:           &deployment.Environment,
+		AutoMerge:             github.Bool(false),
+		RequiredContexts:      &[]string{},
+		TransientEnvironment:  &deployment.Transient,
+		ProductionEnvironment: &deployment.Production,
+	}
+	if deployment.Description != "" {
+		req.Description = &deployment.Description
+	}
+	created, _, err := client.Repositories.CreateDeployment(ctx, owner, name, req)
+	if err != nil {
+		return 0, fmt.Errorf("unable to create deployment: %w", err)
+	}
+	return created.GetID(), nil
+}
+
+// SetDeploymentStatus adds a status to a deployment. A successful deployment marks the previous deployments to the environment inactive.
+//
+// github.repository => repo (alexgartner-bc/my-repo)
+func SetDeploymentStatus(ctx context.Context, repo string, deploymentID int64, state string, description string, logURL string, environmentURL string) error {
+	switch state {
+	case DeploymentStateQueued, DeploymentStatePending, DeploymentStateInProgress, DeploymentStateSuccess,
+		DeploymentStateFailure, DeploymentStateError, DeploymentStateInactive:
+	default:
+		return fmt.Errorf("invalid deployment state %q (queued, pending, in_progress, success, failure, error, inactive)", state)
+	}
+
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	req := &github.DeploymentStatusRequest{
+		State:        &state,
+		AutoInactive: github.Bool(true),
+	}
+	if description != "" {
+		description = truncateStatusDescription(description)
+		req.Description = &description
+	}
+	if logURL != "" {
+		req.LogURL = &logURL
+	}
+	if environmentURL != "" {
+		req.EnvironmentURL = &environmentURL
+	}
+	_, _, err = client.Repositories.CreateDeploymentStatus(ctx, owner, name, deploymentID, req)
+	if err != nil {
+		return fmt.Errorf("unable to create deployment status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeDeployments serves the deployment endpoints of owner/repo and records the request bodies by path
+func newFakeDeployments(t *testing.T) map[string]map[string]interface{} {
+	requests := make(map[string]map[string]interface{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		req := map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests[r.URL.Path] = req
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 42})
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("GITHUB_API_URL", srv.URL+"/api/v3")
+	t.Setenv("GITHUB_TOKEN", "fake")
+	t.Setenv("GITHUB_APP_ID", "")
+	return requests
+}
+
+func TestCreateDeployment(t *testing.T) {
+	requests := newFakeDeployments(t)
+
+	id, err := CreateDeployment(context.Background(), "owner/repo", &Deployment{Ref: "abc123", Environment: "staging", Transient: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(42), id)
+	// ci already decided to deploy: no merge with the default branch and no required status checks
+	require.Equal(t, map[string]interface{}{
+		"ref":                    "abc123",
+		"environment":            "staging",
+		"auto_merge":             false,
+		"required_contexts":      []interface{}{},
+		"transient_environment":  true,
+		"production_environment": false,
+	}, requests["/api/v3/repos/owner/repo/deployments"])
+
+	_, err = CreateDeployment(context.Background(), "owner/repo", &Deployment{Environment: "staging"})
+	require.EqualError(t, err, "deployment ref must be set")
+}
+
+func TestSetDeploymentStatus(t *testing.T) {
+	requests := newFakeDeployments(t)
+
+	err := SetDeploymentStatus(context.Background(), "owner/repo", 42, DeploymentStateSuccess, "deployed", "https://ci/run/1", "https://staging.example.com")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"state":           "success",
+		"description":     "deployed",
+		"log_url":         "https://ci/run/1",
+		"environment_url": "https://staging.example.com",
+		"auto_inactive":   true,
+	}, requests["/api/v3/repos/owner/repo/deployments/42/statuses"])
+
+	err = SetDeploymentStatus(context.Background(), "owner/repo", 42, "done", "", "", "")
+	require.ErrorContains(t, err, `invalid deployment state "done"`)
+	require.Len(t, requests, 1)
+}
@@ -0,0 +1,216 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGithub is a minimal in-memory github api for exercising read-modify-write flows
+type fakeGithub struct {
+	mu sync.Mutex
+	// prBodies by PR number
+	prBodies map[int]string
+	// afterEdit is called (with the lock held) after a PR body is edited
+	afterEdit func(number int)
+	edits     int
+
+	// comments on issue/PR 1 by id
+	comments      map[int64]string
+	nextCommentID int64
+	minimized     map[int64]bool
+	// failMinimize makes the graphql minimize mutation return an error
+	failMinimize bool
+}
+
+// newFakeGithub starts a fake github server and points the github package at it for the duration of the test
+func newFakeGithub(t *testing.T) *fakeGithub {
+	f := &fakeGithub{
+		prBodies:      make(map[int]string),
+		comments:      make(map[int64]string),
+		nextCommentID: 1,
+		minimized:     make(map[int64]bool),
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	t.Setenv("GITHUB_API_URL", srv.URL+"/api/v3")
+	t.Setenv("GITHUB_TOKEN", "fake")
+	t.Setenv("GITHUB_APP_ID", "")
+
+	oldDelay := TrailerSettleDelay
+	TrailerSettleDelay = time.Millisecond
+	t.Cleanup(func() {
+		TrailerSettleDelay = oldDelay
+	})
+	return f
+}
+
+func (f *fakeGithub) body(number int) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.prBodies[number]
+}
+
+// addComment adds a comment to issue 1 and returns its id
+func (f *fakeGithub) addComment(body string) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := f.nextCommentID
+	f.comments[id] = body
+	f.nextCommentID++
+	return id
+}
+
+func (f *fakeGithub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const repo = "/api/v3/repos/owner/repo"
+	switch {
+	case r.URL.Path == "/api/graphql":
+		f.serveGraphQL(w, r)
+		return
+	case r.URL.Path == repo+"/issues/1/comments" || strings.HasPrefix(r.URL.Path, repo+"/issues/comments/"):
+		f.serveComments(w, r)
+		return
+	}
+
+	number, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/v3/repos/owner/repo/pulls/"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		f.mu.Lock()
+		body := f.prBodies[number]
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"number": number, "body": body})
+	case http.MethodPatch:
+		req := struct {
+			Body *string `json:"body"`
+		}{}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		if req.Body != nil {
+			f.prBodies[number] = *req.Body
+		}
+		f.edits++
+		if f.afterEdit != nil {
+			f.afterEdit(number)
+		}
+		body := f.prBodies[number]
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"number": number, "body": body})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func commentJSON(id int64, body string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         id,
+		"node_id":    fmt.Sprintf("C_%d", id),
+		"body":       body,
+		"created_at": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(id) * time.Second),
+	}
+}
+
+// serveComments serves the comments on issue 1, listing pages of per_page like github
+func (f *fakeGithub) serveComments(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	req := struct {
+		Body string `json:"body"`
+	}{}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/issues/1/comments") {
+		switch r.Method {
+		case http.MethodGet:
+			perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+			if perPage == 0 {
+				perPage = 30
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page == 0 {
+				page = 1
+			}
+			var ids []int64
+			for id := range f.comments {
+				ids = append(ids, id)
+			}
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+			comments := []map[string]interface{}{}
+			for i := (page - 1) * perPage; i < len(ids) && i < page*perPage; i++ {
+				comments = append(comments, commentJSON(ids[i], f.comments[ids[i]]))
+			}
+			if page*perPage < len(ids) {
+				next := *r.URL
+				q := next.Query()
+				q.Set("page", strconv.Itoa(page+1))
+				next.RawQuery = q.Encode()
+				w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.String()))
+			}
+			json.NewEncoder(w).Encode(comments)
+		case http.MethodPost:
+			id := f.nextCommentID
+			f.comments[id] = req.Body
+			f.nextCommentID++
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(commentJSON(id, req.Body))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], 10, 64)
+	if _, ok := f.comments[id]; err != nil || !ok {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodPatch:
+		f.comments[id] = req.Body
+		json.NewEncoder(w).Encode(commentJSON(id, req.Body))
+	case http.MethodDelete:
+		delete(f.comments, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// serveGraphQL supports the minimize comment mutation
+func (f *fakeGithub) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	req := graphQLRequest{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f.failMinimize {
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []map[string]string{{"message": "minimize failed"}}})
+		return
+	}
+	var id int64
+	fmt.Sscanf(fmt.Sprint(req.Variables["id"]), "C_%d", &id)
+	f.minimized[id] = true
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{}})
+}
@@ -0,0 +1,76 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v45/github"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphQL runs a query against the graphql api next to the client's rest api and decodes data into res (can be nil)
+func graphQL(ctx context.Context, client *github.Client, query string, variables map[string]interface{}, res interface{}) error {
+	// https://api.github.com/ => https://api.github.com/graphql
+	// https://ghes.example.com/api/v3/ => https://ghes.example.com/api/graphql
+	u, err := client.BaseURL.Parse("../graphql")
+	if err != nil {
+		return err
+	}
+	req, err := client.NewRequest("POST", u.String(), &graphQLRequest{
+		Query:     query,
+		Variables: variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	gqlRes := &graphQLResponse{}
+	_, err = client.Do(ctx, req, gqlRes)
+	if err != nil {
+		return err
+	}
+	if len(gqlRes.Errors) > 0 {
+		var messages []string
+		for _, e := range gqlRes.Errors {
+			messages = append(messages, e.Message)
+		}
+		return errors.New(strings.Join(messages, "; "))
+	}
+	if res == nil {
+		return nil
+	}
+	err = json.Unmarshal(gqlRes.Data, res)
+	if err != nil {
+		return fmt.Errorf("unable to decode graphql response: %w", err)
+	}
+	return nil
+}
+
+const minimizeCommentMutation = `mutation($id: ID!) {
+  minimizeComment(input: {subjectId: $id, classifier: OUTDATED}) {
+    minimizedComment { isMinimized }
+  }
+}`
+
+// minimizeComment hides a comment (issue, pr or commit comment) as outdated
+func minimizeComment(ctx context.Context, client *github.Client, nodeID string) error {
+	err := graphQL(ctx, client, minimizeCommentMutation, map[string]interface{}{"id": nodeID}, nil)
+	if err != nil {
+		return fmt.Errorf("unable to minimize comment: %w", err)
+	}
+	return nil
+}
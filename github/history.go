@@ -0,0 +1,145 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHistoryCount = 5
+	historyStart        = "\n<!-- history -->\n"
+	historyEnd          = "\n<!-- history end -->\n"
+	// don't bother keeping a previous run truncated to less than this
+	minHistoryEntrySize = 1024
+)
+
+// historyEntry is one version of a sticky comment's text
+type historyEntry struct {
+	Time time.Time
+	SHA  string
+	Text string
+}
+
+var historyRunRe = regexp.MustCompile(`\n<!-- run (\S+) (\S*) -->\n`)
+
+func historyRunText(entry historyEntry) string {
+	return fmt.Sprintf("\n<!-- run %s %s -->\n", entry.Time.UTC().Format(time.RFC3339), entry.SHA)
+}
+
+// historyEntryHeader is shown above a previous run
+func historyEntryHeader(entry historyEntry) string {
+	header := fmt.Sprintf("**%s**", entry.Time.UTC().Format("2006-01-02 15:04 UTC"))
+	if entry.SHA != "" {
+		sha := entry.SHA
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		header += fmt.Sprintf(" (`%s`)", sha)
+	}
+	return header + "\n\n"
+}
+
+// parseHistory splits the body of a sticky comment (without the key) into its latest text and previous runs, newest first.
+// Comments posted without history have no run marker, their time is updatedAt.
+func parseHistory(body string, updatedAt time.Time) (historyEntry, []historyEntry) {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	head, history := body, ""
+	if i := strings.Index(body, historyStart); i >= 0 {
+		head, history = body[:i], body[i+len(historyStart):]
+	}
+
+	current := historyEntry{Time: updatedAt, Text: head}
+	if loc := lastIndexRe(historyRunRe, head); loc != nil {
+		current = historyEntry{
+			Time: parseHistoryTime(head[loc[2]:loc[3]], updatedAt),
+			SHA:  head[loc[4]:loc[5]],
+			Text: head[:loc[0]],
+		}
+	}
+
+	if i := strings.Index(history, historyEnd); i >= 0 {
+		history = strings.TrimSuffix(history[:i], "\n</details>")
+	}
+	var previous []historyEntry
+	markers := historyRunRe.FindAllStringSubmatchIndex(history, -1)
+	for i, loc := range markers {
+		end := len(history)
+		if i+1 < len(markers) {
+			end = markers[i+1][0]
+		}
+		entry := historyEntry{
+			Time: parseHistoryTime(history[loc[2]:loc[3]], updatedAt),
+			SHA:  history[loc[4]:loc[5]],
+		}
+		entry.Text = strings.TrimPrefix(history[loc[1]:end], historyEntryHeader(entry))
+		previous = append(previous, entry)
+	}
+	return current, previous
+}
+
+func lastIndexRe(re *regexp.Regexp, s string) []int {
+	all := re.FindAllStringSubmatchIndex(s, -1)
+	if len(all) == 0 {
+		return nil
+	}
+	return all[len(all)-1]
+}
+
+func parseHistoryTime(value string, fallback time.Time) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fallback
+	}
+	return t
+}
+
+// withHistory returns text followed by the previous runs from previousBody (the existing comment without key, "" if none).
+// The previous text is only added to the history if it differs from text. At most count runs and size bytes of history are kept,
+// and never more than fits in a comment next to text and the key.
+func withHistory(text string, sha string, now time.Time, previousBody string, updatedAt time.Time, count int, size int, stickyKey string) string {
+	current := historyEntry{Time: now, SHA: sha, Text: text}
+	var previous []historyEntry
+	if previousBody != "" {
+		last, older := parseHistory(previousBody, updatedAt)
+		if last.Text != text {
+			previous = append(previous, last)
+		}
+		previous = append(previous, older...)
+	}
+
+	body := text + historyRunText(current)
+	if count <= 0 {
+		count = defaultHistoryCount
+	}
+	const wrapper = historyStart + "<details><summary>Previous runs (000)</summary>\n" + "\n</details>" + historyEnd
+	room := MaxBodyLength - len(body) - len(stickyKeyText(stickyKey)) - len(wrapper)
+	if size > 0 && size < room {
+		room = size
+	}
+
+	var sb strings.Builder
+	kept := 0
+	for _, entry := range previous {
+		if kept >= count {
+			break
+		}
+		entryText := historyRunText(entry) + historyEntryHeader(entry) + ensureNewline(entry.Text)
+		if sb.Len()+len(entryText) > room {
+			// truncate the newest run rather than dropping all history
+			remaining := room - sb.Len() - (len(entryText) - len(entry.Text))
+			if kept > 0 || remaining < minHistoryEntrySize {
+				break
+			}
+			entry.Text = TruncateMarkdown(entry.Text, remaining-1, truncatedCommentNote)
+			entryText = historyRunText(entry) + historyEntryHeader(entry) + ensureNewline(entry.Text)
+		}
+		sb.WriteString(entryText)
+		kept++
+	}
+	if kept == 0 {
+		return body
+	}
+	return fmt.Sprintf("%s%s<details><summary>Previous runs (%d)</summary>\n%s\n</details>%s", body, historyStart, kept, sb.String(), historyEnd)
+}
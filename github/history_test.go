@@ -0,0 +1,69 @@
+package github
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHistory(t *testing.T) {
+	key := "preview"
+	t1 := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	t3 := t2.Add(time.Hour)
+
+	// a comment posted before history was turned on
+	body := withHistory("run 2\n", "bbbbbbbbbb", t2, "run 1\n", t1, 0, 0, key)
+	require.Equal(t, "run 2\n"+
+		"\n<!-- run 2022-08-01T11:00:00Z bbbbbbbbbb -->\n"+
+		"\n<!-- history -->\n<details><summary>Previous runs (1)</summary>\n"+
+		"\n<!-- run 2022-08-01T10:00:00Z  -->\n**2022-08-01 10:00 UTC**\n\nrun 1\n"+
+		"\n</details>\n<!-- history end -->\n", body)
+
+	current, previous := parseHistory(body, t3)
+	require.Equal(t, historyEntry{Time: t2, SHA: "bbbbbbbbbb", Text: "run 2\n"}, current)
+	require.Equal(t, []historyEntry{{Time: t1, Text: "run 1\n"}}, previous)
+
+	// unchanged text isn't added to the history again
+	require.Equal(t, strings.Replace(body, "2022-08-01T11:00:00Z", "2022-08-01T12:00:00Z", 1),
+		withHistory("run 2\n", "bbbbbbbbbb", t3, body, t3, 0, 0, key))
+
+	body = withHistory("run 3\n", "cccccccccc", t3, body, t3, 0, 0, key)
+	current, previous = parseHistory(body, t3)
+	require.Equal(t, "run 3\n", current.Text)
+	require.Equal(t, []historyEntry{
+		{Time: t2, SHA: "bbbbbbbbbb", Text: "run 2\n"},
+		{Time: t1, Text: "run 1\n"},
+	}, previous)
+	require.Contains(t, body, "**2022-08-01 11:00 UTC** (`bbbbbbb`)")
+
+	// bounded by count
+	_, previous = parseHistory(withHistory("run 4\n", "", t3, body, t3, 1, 0, key), t3)
+	require.Len(t, previous, 1)
+	require.Equal(t, "run 3\n", previous[0].Text)
+}
+
+func TestWithHistorySize(t *testing.T) {
+	key := "preview"
+	now := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	big := strings.Repeat("line\n", MaxBodyLength/5)
+
+	// the previous run is truncated to fit
+	body := withHistory(strings.Repeat("new\n", 1000), "", now, big, now, 0, 0, key)
+	require.LessOrEqual(t, len(body+stickyKeyText(key)), MaxBodyLength)
+	_, previous := parseHistory(body, now)
+	require.Len(t, previous, 1)
+	require.Contains(t, previous[0].Text, "more lines truncated")
+
+	// no room left at all
+	body = withHistory(big, "", now, "old\n", now, 0, 0, key)
+	require.NotContains(t, body, historyStart)
+
+	// bounded by size
+	body = withHistory("new\n", "", now, strings.Repeat("old\n", 1000), now, 0, 2048, key)
+	_, previous = parseHistory(body, now)
+	require.Len(t, previous, 1)
+	require.Less(t, len(previous[0].Text), 2048)
+}
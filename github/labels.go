@@ -0,0 +1,76 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// SetLabels adds and removes labels on an issue or PR. Adding a label it already has or removing one it doesn't have is not an error.
+//
+// github.repository => repo (alexgartner-bc/my-repo)
+// github.event.issue.number => number
+func SetLabels(ctx context.Context, repo string, number int, add []string, remove []string) error {
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	current, err := listLabels(ctx, client, owner, name, number)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, label := range add {
+		if !current[strings.ToLower(label)] {
+			missing = append(missing, label)
+		}
+	}
+	if len(missing) > 0 {
+		_, _, err = client.Issues.AddLabelsToIssue(ctx, owner, name, number, missing)
+		if err != nil {
+			return fmt.Errorf("unable to add labels: %w", err)
+		}
+	}
+
+	for _, label := range remove {
+		if !current[strings.ToLower(label)] {
+			continue
+		}
+		_, err = client.Issues.RemoveLabelForIssue(ctx, owner, name, number, label)
+		if err != nil {
+			return fmt.Errorf("unable to remove label %s: %w", label, err)
+		}
+	}
+	return nil
+}
+
+// listLabels returns the (lower cased, github labels are case insensitive) labels on an issue or PR
+func listLabels(ctx context.Context, client *github.Client, owner string, name string, number int) (map[string]bool, error) {
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+	res := make(map[string]bool)
+	for {
+		labels, resp, err := client.Issues.ListLabelsByIssue(ctx, owner, name, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list labels: %w", err)
+		}
+		for _, label := range labels {
+			res[strings.ToLower(label.GetName())] = true
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return res, nil
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLabels(t *testing.T) {
+	labels := map[string]bool{"WIP": true, "infra:destructive": true}
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const path = "/api/v3/repos/owner/repo/issues/3/labels"
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == path:
+			var res []map[string]string
+			for label := range labels {
+				res = append(res, map[string]string{"name": label})
+			}
+			json.NewEncoder(w).Encode(res)
+		case r.Method == http.MethodPost && r.URL.Path == path:
+			var add []string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&add))
+			for _, label := range add {
+				labels[label] = true
+			}
+			w.Write([]byte("[]"))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, path+"/"):
+			delete(labels, strings.TrimPrefix(r.URL.Path, path+"/"))
+			w.Write([]byte("[]"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL+"/api/v3")
+	t.Setenv("GITHUB_TOKEN", "fake")
+	t.Setenv("GITHUB_APP_ID", "")
+
+	err := SetLabels(context.Background(), "owner/repo", 3, []string{"wip", "tests:flaky"}, []string{"infra:destructive", "not-there"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"WIP": true, "tests:flaky": true}, labels)
+	// only the missing label is added and only the present label is removed
+	require.Equal(t, []string{
+		"GET /api/v3/repos/owner/repo/issues/3/labels",
+		"POST /api/v3/repos/owner/repo/issues/3/labels",
+		"DELETE /api/v3/repos/owner/repo/issues/3/labels/infra:destructive",
+	}, requests)
+}
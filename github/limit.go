@@ -0,0 +1,138 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxBodyLength is the most characters github accepts in a comment or PR body.
+// Lengths are measured in bytes here which is never less than github's count.
+const MaxBodyLength = 65536
+
+// TruncateMarkdown cuts text on a line boundary so the result (including the note) is at most max bytes.
+// A code fence left open by the cut is closed. moreFormat is appended with the number of dropped lines (%d).
+func TruncateMarkdown(text string, max int, moreFormat string) string {
+	if len(text) <= max {
+		return text
+	}
+	lines := strings.SplitAfter(text, "\n")
+	totalLines := countLines(lines)
+
+	best := -1
+	prefixLen := 0
+	keptLines := 0
+	open := false
+	for keep := 0; keep <= len(lines); keep++ {
+		if keep > 0 {
+			line := lines[keep-1]
+			prefixLen += len(line)
+			if line != "" {
+				keptLines++
+			}
+			if isFenceLine(line) {
+				open = !open
+			}
+		}
+		length := prefixLen
+		if keep > 0 && !strings.HasSuffix(lines[keep-1], "\n") {
+			length++
+		}
+		if open {
+			length += len("```\n")
+		}
+		length += len(fmt.Sprintf(moreFormat, totalLines-keptLines))
+		if length > max {
+			break
+		}
+		best = keep
+	}
+	if best < 0 {
+		// not even the note fits
+		return ""
+	}
+
+	res := strings.Join(lines[:best], "")
+	if inCodeFence(res) {
+		res = ensureNewline(res) + "```\n"
+	}
+	return ensureNewline(res) + fmt.Sprintf(moreFormat, totalLines-countLines(lines[:best]))
+}
+
+// splitMarkdown splits text on line boundaries into parts of at most max bytes.
+// Code fences are closed at the end of a part and reopened at the start of the next one.
+// Lines longer than max are cut.
+func splitMarkdown(text string, max int) []string {
+	if len(text) <= max {
+		return []string{text}
+	}
+	var parts []string
+	current := ""
+	fence := ""
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		closing := ""
+		if fence != "" {
+			closing = "\n```\n"
+		}
+		if current != "" && len(current)+len(line)+len(closing) > max {
+			parts = append(parts, ensureNewline(current)+strings.TrimPrefix(closing, "\n"))
+			current = fence
+		}
+		for len(current)+len(line)+len(closing) > max {
+			// a single line longer than a part
+			cut := max - len(current) - len(closing)
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			parts = append(parts, current+line[:cut]+closing)
+			current = fence
+			line = line[cut:]
+		}
+		current += line
+		if isFenceLine(line) {
+			if fence == "" {
+				fence = strings.TrimSpace(line) + "\n"
+			} else {
+				fence = ""
+			}
+		}
+	}
+	if current != "" && current != fence {
+		parts = append(parts, current)
+	}
+	return parts
+}
+
+func isFenceLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+func inCodeFence(text string) bool {
+	open := false
+	for _, line := range strings.Split(text, "\n") {
+		if isFenceLine(line) {
+			open = !open
+		}
+	}
+	return open
+}
+
+func ensureNewline(text string) string {
+	if text == "" || strings.HasSuffix(text, "\n") {
+		return text
+	}
+	return text + "\n"
+}
+
+func countLines(lines []string) int {
+	count := 0
+	for _, line := range lines {
+		if line != "" {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,51 @@
+package github
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateMarkdown(t *testing.T) {
+	text := "summary\n```\nline 1\nline 2\nline 3\n```\n"
+
+	require.Equal(t, text, TruncateMarkdown(text, len(text), "%d more\n"))
+
+	res := TruncateMarkdown(text, 32, "%d more\n")
+	require.Equal(t, "summary\n```\nline 1\n```\n3 more\n", res)
+	require.LessOrEqual(t, len(res), 32)
+
+	require.Equal(t, "", TruncateMarkdown(text, 3, "%d more\n"))
+}
+
+func TestSplitMarkdown(t *testing.T) {
+	text := "summary\n```\n" + strings.Repeat("0123456789\n", 10) + "```\nafter\n"
+
+	parts := splitMarkdown(text, 40)
+	require.Greater(t, len(parts), 1)
+	for _, part := range parts {
+		require.LessOrEqual(t, len(part), 40)
+		require.False(t, inCodeFence(part), part)
+	}
+	require.Equal(t, strings.Count(text, "0123456789"), strings.Count(strings.Join(parts, ""), "0123456789"))
+	require.True(t, strings.HasSuffix(parts[len(parts)-1], "after\n"))
+}
+
+func TestCommentParts(t *testing.T) {
+	text := strings.Repeat("a line of text\n", MaxBodyLength/10)
+
+	parts := commentParts(text, "my-key", false)
+	require.Len(t, parts, 1)
+	require.LessOrEqual(t, len(parts[0]), MaxBodyLength)
+	require.True(t, strings.HasSuffix(parts[0], stickyKeyText("my-key")))
+
+	parts = commentParts(text, "my-key", true)
+	require.Len(t, parts, 2)
+	for i, part := range parts {
+		require.LessOrEqual(t, len(part), MaxBodyLength)
+		require.True(t, hasStickyKey(part, "my-key"))
+		require.True(t, strings.HasSuffix(part, partKeyText("my-key", i)))
+	}
+	require.False(t, hasStickyKey(parts[1], "my"))
+}
@@ -0,0 +1,459 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// ReleaseNoteEntry is a merged PR between two refs, or a commit which wasn't merged with a PR (Number is 0)
+type ReleaseNoteEntry struct {
+	Number   int
+	Title    string
+	URL      string
+	Author   string
+	SHA      string
+	Labels   []string
+	JiraKeys []string
+}
+
+// ListReleaseNoteEntries returns the PRs merged into base between from (excluded) and to, oldest first.
+// base defaults to the default branch of the repo.
+//
+// github.repository => repo (alexgartner-bc/my-repo)
+func ListReleaseNoteEntries(ctx context.Context, repo string, from string, to string, base string) ([]*ReleaseNoteEntry, error) {
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if base == "" {
+		r, _, err := client.Repositories.Get(ctx, owner, name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get default branch: %w", err)
+		}
+		base = r.GetDefaultBranch()
+	}
+
+	var commits []*github.RepositoryCommit
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		comparison, resp, err := client.Repositories.CompareCommits(ctx, owner, name, from, to, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to compare %s...%s: %w", from, to, err)
+		}
+		commits = append(commits, comparison.Commits...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	prsByCommit, err := associatedPullRequests(ctx, client, commits)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*ReleaseNoteEntry
+	seenPRs := make(map[int]bool)
+	for _, commit := range commits {
+		pr := mergedPR(prsByCommit[commit.GetSHA()], commit.GetSHA(), base)
+		if pr == nil {
+			message := commit.GetCommit().GetMessage()
+			res = append(res, &ReleaseNoteEntry{
+				Title:    strings.SplitN(message, "\n", 2)[0],
+				URL:      commit.GetHTMLURL(),
+				Author:   commit.GetAuthor().GetLogin(),
+				SHA:      commit.GetSHA(),
+				JiraKeys: findJiraKeys(message),
+			})
+			continue
+		}
+		if seenPRs[pr.GetNumber()] {
+			continue
+		}
+		seenPRs[pr.GetNumber()] = true
+
+		entry := &ReleaseNoteEntry{
+			Number:   pr.GetNumber(),
+			Title:    pr.GetTitle(),
+			URL:      pr.GetHTMLURL(),
+			Author:   pr.GetUser().GetLogin(),
+			SHA:      pr.GetMergeCommitSHA(),
+			JiraKeys: findJiraKeys(pr.GetTitle() + "\n" + pr.GetHead().GetRef() + "\n" + pr.GetBody()),
+		}
+		for _, label := range pr.Labels {
+			entry.Labels = append(entry.Labels, label.GetName())
+		}
+		res = append(res, entry)
+	}
+	return res, nil
+}
+
+// mergedPR returns the PR which merged sha into base, preferring the one whose merge commit it is
+func mergedPR(prs []*github.PullRequest, sha string, base string) *github.PullRequest {
+	var res *github.PullRequest
+	for _, pr := range prs {
+		if pr.MergedAt == nil || pr.GetBase().GetRef() != base {
+			continue
+		}
+		if pr.GetMergeCommitSHA() == sha {
+			return pr
+		}
+		if res == nil {
+			res = pr
+		}
+	}
+	return res
+}
+
+const associatedPullRequestsQuery = `query($ids: [ID!]!) {
+  nodes(ids: $ids) {
+    ... on Commit {
+      oid
+      associatedPullRequests(first: 10) {
+        nodes {
+          number
+          title
+          url
+          body
+          mergedAt
+          baseRefName
+          headRefName
+          author { login }
+          mergeCommit { oid }
+          labels(first: 50) { nodes { name } }
+        }
+      }
+    }
+  }
+}`
+
+type graphQLCommitPullRequests struct {
+	OID                    string `json:"oid"`
+	AssociatedPullRequests struct {
+		Nodes []struct {
+			Number      int        `json:"number"`
+			Title       string     `json:"title"`
+			URL         string     `json:"url"`
+			Body        string     `json:"body"`
+			MergedAt    *time.Time `json:"mergedAt"`
+			BaseRefName string     `json:"baseRefName"`
+			HeadRefName string     `json:"headRefName"`
+			Author      *struct {
+				Login string `json:"login"`
+			} `json:"author"`
+			MergeCommit *struct {
+				OID string `json:"oid"`
+			} `json:"mergeCommit"`
+			Labels struct {
+				Nodes []struct {
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"labels"`
+		} `json:"nodes"`
+	} `json:"associatedPullRequests"`
+}
+
+// associatedPullRequests returns the PRs of each commit by sha. The rest api needs a request per commit,
+// graphql takes 100 commits at a time.
+func associatedPullRequests(ctx context.Context, client *github.Client, commits []*github.RepositoryCommit) (map[string][]*github.PullRequest, error) {
+	res := make(map[string][]*github.PullRequest)
+	for start := 0; start < len(commits); start += 100 {
+		end := start + 100
+		if end > len(commits) {
+			end = len(commits)
+		}
+		var ids []string
+		for _, commit := range commits[start:end] {
+			ids = append(ids, commit.GetNodeID())
+		}
+		data := struct {
+			Nodes []*graphQLCommitPullRequests `json:"nodes"`
+		}{}
+		err := graphQL(ctx, client, associatedPullRequestsQuery, map[string]interface{}{"ids": ids}, &data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list PRs of commits: %w", err)
+		}
+		for _, commit := range data.Nodes {
+			if commit == nil {
+				continue
+			}
+			for _, node := range commit.AssociatedPullRequests.Nodes {
+				pr := &github.PullRequest{
+					Number:   github.Int(node.Number),
+					Title:    github.String(node.Title),
+					HTMLURL:  github.String(node.URL),
+					Body:     github.String(node.Body),
+					Base:     &github.PullRequestBranch{Ref: github.String(node.BaseRefName)},
+					Head:     &github.PullRequestBranch{Ref: github.String(node.HeadRefName)},
+					MergedAt: node.MergedAt,
+				}
+				if node.Author != nil {
+					pr.User = &github.User{Login: github.String(node.Author.Login)}
+				}
+				if node.MergeCommit != nil {
+					pr.MergeCommitSHA = github.String(node.MergeCommit.OID)
+				}
+				for _, label := range node.Labels.Nodes {
+					pr.Labels = append(pr.Labels, &github.Label{Name: github.String(label.Name)})
+				}
+				res[commit.OID] = append(res[commit.OID], pr)
+			}
+		}
+	}
+	return res, nil
+}
+
+var jiraKeyRe = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-[1-9][0-9]*\b`)
+
+// findJiraKeys returns the unique jira keys (ABC-123) in text in the order they appear
+func findJiraKeys(text string) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, key := range jiraKeyRe.FindAllString(text, -1) {
+		if !seen[key] {
+			seen[key] = true
+			res = append(res, key)
+		}
+	}
+	return res
+}
+
+// ReleaseNotesLabelGroup puts entries with Label in Section
+type ReleaseNotesLabelGroup struct {
+	Label   string
+	Section string
+}
+
+// ReleaseNotesOptions changes how release notes are rendered
+type ReleaseNotesOptions struct {
+	// LabelGroups are checked in order, entries without a matching label are grouped by their conventional commit type
+	LabelGroups []ReleaseNotesLabelGroup
+	// JiraURL links jira keys when set (https://example.atlassian.net)
+	JiraURL string
+	// JiraProjects only keeps keys of these projects when set (ABC)
+	JiraProjects []string
+}
+
+var conventionalCommitRe = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+const breakingSection = "Breaking Changes"
+
+// conventional commit types in the order their sections are shown
+var conventionalSections = []struct {
+	section string
+	types   []string
+}{
+	{"Features", []string{"feat", "feature"}},
+	{"Bug Fixes", []string{"fix", "bugfix"}},
+	{"Performance", []string{"perf"}},
+	{"Documentation", []string{"docs"}},
+	{"Maintenance", []string{"refactor", "chore", "ci", "build", "test", "style", "deps"}},
+}
+
+const otherSection = "Other"
+
+// releaseNoteSection returns the section of an entry and its title without the conventional commit type
+func releaseNoteSection(entry *ReleaseNoteEntry, opts *ReleaseNotesOptions) (string, string) {
+	title := entry.Title
+	commitType, breaking := "", false
+	if matches := conventionalCommitRe.FindStringSubmatch(entry.Title); matches != nil {
+		commitType, breaking = strings.ToLower(matches[1]), matches[3] == "!"
+		title = matches[4]
+		if matches[2] != "" {
+			title = matches[2] + ": " + title
+		}
+	}
+
+	for _, group := range opts.LabelGroups {
+		for _, label := range entry.Labels {
+			if strings.EqualFold(label, group.Label) {
+				return group.Section, title
+			}
+		}
+	}
+	if breaking {
+		return breakingSection, title
+	}
+	for _, s := range conventionalSections {
+		for _, t := range s.types {
+			if t == commitType {
+				return s.section, title
+			}
+		}
+	}
+	return otherSection, entry.Title
+}
+
+// ReleaseNotesMarkdown groups entries into sections (label groups, breaking changes, conventional commit types, other)
+func ReleaseNotesMarkdown(entries []*ReleaseNoteEntry, opts *ReleaseNotesOptions) string {
+	if opts == nil {
+		opts = &ReleaseNotesOptions{}
+	}
+
+	var order []string
+	for _, group := range opts.LabelGroups {
+		order = append(order, group.Section)
+	}
+	order = append(order, breakingSection)
+	for _, s := range conventionalSections {
+		order = append(order, s.section)
+	}
+	order = append(order, otherSection)
+
+	lines := make(map[string][]string)
+	var jiraKeys []string
+	seenJiraKeys := make(map[string]bool)
+	for _, entry := range entries {
+		section, title := releaseNoteSection(entry, opts)
+
+		line := "- " + title
+		if entry.Number != 0 {
+			line += fmt.Sprintf(" ([#%d](%s))", entry.Number, entry.URL)
+		} else if len(entry.SHA) >= 7 {
+			line += fmt.Sprintf(" ([%s](%s))", entry.SHA[:7], entry.URL)
+		}
+		if entry.Author != "" {
+			line += " @" + entry.Author
+		}
+		for _, key := range entry.JiraKeys {
+			if !jiraProjectAllowed(key, opts.JiraProjects) {
+				continue
+			}
+			line += " " + jiraKeyMarkdown(key, opts.JiraURL)
+			if !seenJiraKeys[key] {
+				seenJiraKeys[key] = true
+				jiraKeys = append(jiraKeys, key)
+			}
+		}
+		lines[section] = append(lines[section], line)
+	}
+
+	var sb strings.Builder
+	seenSections := make(map[string]bool)
+	for _, section := range order {
+		if seenSections[section] || len(lines[section]) == 0 {
+			continue
+		}
+		seenSections[section] = true
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "### %s\n\n%s\n", section, strings.Join(lines[section], "\n"))
+	}
+	if len(jiraKeys) > 0 {
+		sort.Strings(jiraKeys)
+		var links []string
+		for _, key := range jiraKeys {
+			links = append(links, jiraKeyMarkdown(key, opts.JiraURL))
+		}
+		fmt.Fprintf(&sb, "\n### Jira\n\n%s\n", strings.Join(links, ", "))
+	}
+	if sb.Len() == 0 {
+		return "No changes\n"
+	}
+	return sb.String()
+}
+
+// notJiraProjects look like jira keys but are standards and encodings (UTF-8, SHA-256, ISO-8601...)
+var notJiraProjects = map[string]bool{
+	"AES": true, "CVE": true, "CWE": true, "ECMA": true, "IEEE": true, "ISO": true, "MD": true,
+	"PEP": true, "RFC": true, "SHA": true, "TLS": true, "UCS": true, "UTF": true,
+}
+
+// jiraProjectAllowed is true if key is in one of projects. Without projects any key is allowed but standards (UTF-8...).
+func jiraProjectAllowed(key string, projects []string) bool {
+	project := strings.SplitN(key, "-", 2)[0]
+	if len(projects) == 0 {
+		return !notJiraProjects[project]
+	}
+	for _, p := range projects {
+		if p == project {
+			return true
+		}
+	}
+	return false
+}
+
+func jiraKeyMarkdown(key string, jiraURL string) string {
+	if jiraURL == "" {
+		return key
+	}
+	return fmt.Sprintf("[%s](%s/browse/%s)", key, strings.TrimSuffix(jiraURL, "/"), key)
+}
+
+// UpsertDraftRelease creates a draft release for tag, or updates the existing draft, and returns its url.
+// It is an error if the release for tag is already published.
+//
+// github.repository => repo (alexgartner-bc/my-repo)
+func UpsertDraftRelease(ctx context.Context, repo string, tag string, target string, name string, body string) (string, error) {
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return "", err
+	}
+
+	owner, repoName, err := splitRepo(repo)
+	if err != nil {
+		return "", err
+	}
+
+	if name == "" {
+		name = tag
+	}
+	body = TruncateMarkdown(body, MaxBodyLength, truncatedCommentNote)
+	release := &github.RepositoryRelease{
+		TagName: &tag,
+		Name:    &name,
+		Body:    &body,
+		Draft:   github.Bool(true),
+	}
+	if target != "" {
+		release.TargetCommitish = &target
+	}
+
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		releases, resp, err := client.Repositories.ListReleases(ctx, owner, repoName, opts)
+		if err != nil {
+			return "", fmt.Errorf("unable to list releases: %w", err)
+		}
+		for _, existing := range releases {
+			if existing.GetTagName() != tag {
+				continue
+			}
+			if !existing.GetDraft() {
+				return "", fmt.Errorf("release %s is already published", tag)
+			}
+			edited, _, err := client.Repositories.EditRelease(ctx, owner, repoName, existing.GetID(), release)
+			if err != nil {
+				return "", fmt.Errorf("unable to edit release: %w", err)
+			}
+			return edited.GetHTMLURL(), nil
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	created, _, err := client.Repositories.CreateRelease(ctx, owner, repoName, release)
+	if err != nil {
+		return "", fmt.Errorf("unable to create release: %w", err)
+	}
+	return created.GetHTMLURL(), nil
+}
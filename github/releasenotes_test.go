@@ -0,0 +1,151 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseNotesMarkdown(t *testing.T) {
+	entries := []*ReleaseNoteEntry{
+		{Number: 1, Title: "feat(api): add billing endpoint", URL: "https://github.com/o/r/pull/1", Author: "alice", JiraKeys: []string{"PAY-12"}},
+		{Number: 2, Title: "fix: handle empty body", URL: "https://github.com/o/r/pull/2", Author: "bob"},
+		{Number: 3, Title: "feat!: drop v1 api", URL: "https://github.com/o/r/pull/3", Author: "alice", JiraKeys: []string{"PAY-9", "UTF-8"}},
+		{Number: 4, Title: "bump terraform", URL: "https://github.com/o/r/pull/4", Author: "renovate", Labels: []string{"Dependencies"}},
+		{Title: "quick hotfix", SHA: "0123456789", URL: "https://github.com/o/r/commit/0123456789", Author: "carol"},
+	}
+	md := ReleaseNotesMarkdown(entries, &ReleaseNotesOptions{
+		LabelGroups:  []ReleaseNotesLabelGroup{{Label: "dependencies", Section: "Dependencies"}},
+		JiraURL:      "https://example.atlassian.net/",
+		JiraProjects: []string{"PAY"},
+	})
+	require.Equal(t, `### Dependencies
+
+- bump terraform ([#4](https://github.com/o/r/pull/4)) @renovate
+
+### Breaking Changes
+
+- drop v1 api ([#3](https://github.com/o/r/pull/3)) @alice [PAY-9](https://example.atlassian.net/browse/PAY-9)
+
+### Features
+
+- api: add billing endpoint ([#1](https://github.com/o/r/pull/1)) @alice [PAY-12](https://example.atlassian.net/browse/PAY-12)
+
+### Bug Fixes
+
+- handle empty body ([#2](https://github.com/o/r/pull/2)) @bob
+
+### Other
+
+- quick hotfix ([0123456](https://github.com/o/r/commit/0123456789)) @carol
+
+### Jira
+
+[PAY-12](https://example.atlassian.net/browse/PAY-12), [PAY-9](https://example.atlassian.net/browse/PAY-9)
+`, md)
+
+	require.Equal(t, "No changes\n", ReleaseNotesMarkdown(nil, nil))
+}
+
+func TestListReleaseNoteEntries(t *testing.T) {
+	pr := func(number int, base string, mergeCommit string) map[string]interface{} {
+		return map[string]interface{}{
+			"number":      number,
+			"title":       "fix: PR " + base,
+			"url":         "https://github.com/owner/repo/pull/1",
+			"mergedAt":    "2024-01-02T03:04:05Z",
+			"baseRefName": base,
+			"headRefName": "PAY-1-branch",
+			"author":      map[string]string{"login": "alice"},
+			"mergeCommit": map[string]string{"oid": mergeCommit},
+			"labels":      map[string]interface{}{"nodes": []map[string]string{{"name": "bug"}}},
+		}
+	}
+	// c2 was cherry picked from a PR into release-1 before its PR into main was merged (as c3)
+	associated := map[string][]map[string]interface{}{
+		"C_c1": {pr(1, "main", "c1")},
+		"C_c2": {pr(2, "release-1", "r1"), pr(3, "main", "c3")},
+		"C_c3": {pr(3, "main", "c3")},
+		"C_c4": {pr(4, "release-1", "r2")},
+	}
+	graphQLCalls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo":
+			w.Write([]byte(`{"default_branch": "main"}`))
+		case "/api/v3/repos/owner/repo/compare/v1...v2":
+			var commits []map[string]interface{}
+			for _, sha := range []string{"c1", "c2", "c3", "c4"} {
+				commits = append(commits, map[string]interface{}{
+					"sha":     sha,
+					"node_id": "C_" + sha,
+					"commit":  map[string]string{"message": "commit " + sha + "\n\nbody"},
+					"author":  map[string]string{"login": "bob"},
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"commits": commits})
+		case "/api/graphql":
+			graphQLCalls++
+			req := struct {
+				Variables struct {
+					IDs []string `json:"ids"`
+				} `json:"variables"`
+			}{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			var nodes []map[string]interface{}
+			for _, id := range req.Variables.IDs {
+				nodes = append(nodes, map[string]interface{}{
+					"oid":                    id[2:],
+					"associatedPullRequests": map[string]interface{}{"nodes": associated[id]},
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"nodes": nodes}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL+"/api/v3")
+	t.Setenv("GITHUB_TOKEN", "fake")
+	t.Setenv("GITHUB_APP_ID", "")
+
+	entries, err := ListReleaseNoteEntries(context.Background(), "owner/repo", "v1", "v2", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, graphQLCalls)
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Title)
+	}
+	require.Equal(t, []string{"fix: PR main", "fix: PR main", "commit c4"}, got)
+	require.Equal(t, 1, entries[0].Number)
+	require.Equal(t, 3, entries[1].Number)
+	require.Equal(t, "c3", entries[1].SHA)
+	require.Equal(t, []string{"bug"}, entries[1].Labels)
+	require.Equal(t, []string{"PAY-1"}, entries[1].JiraKeys)
+	require.Equal(t, "bob", entries[2].Author)
+
+	entries, err = ListReleaseNoteEntries(context.Background(), "owner/repo", "v1", "v2", "release-1")
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	require.Equal(t, "commit c1", entries[0].Title)
+	require.Equal(t, 2, entries[1].Number)
+	require.Equal(t, "commit c3", entries[2].Title)
+	require.Equal(t, 4, entries[3].Number)
+}
+
+func TestFindJiraKeys(t *testing.T) {
+	require.Equal(t, []string{"ABC-1", "DEF-22"}, findJiraKeys("ABC-1 fix things\nfeature/DEF-22-thing ABC-1 abc-3 ABC-0"))
+}
+
+func TestJiraProjectAllowed(t *testing.T) {
+	for _, key := range []string{"UTF-8", "SHA-256", "ISO-8601", "RFC-3339"} {
+		require.False(t, jiraProjectAllowed(key, nil), key)
+	}
+	require.True(t, jiraProjectAllowed("ABC-1", nil))
+	require.True(t, jiraProjectAllowed("ABC-1", []string{"ABC"}))
+	require.False(t, jiraProjectAllowed("DEF-1", []string{"ABC"}))
+}
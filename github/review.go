@@ -0,0 +1,363 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// ReviewComment is a comment on a line of a file in a PR
+type ReviewComment struct {
+	Path string
+	Line int
+	Body string
+}
+
+// ReviewResult counts what SubmitReview did with the comments
+type ReviewResult struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Resolved  int
+	// OutsideDiff comments were dropped because github only allows review comments on lines in the diff
+	OutsideDiff int
+}
+
+func reviewLineKeyText(stickyKey string, path string, line int) string {
+	return fmt.Sprintf("\n<!-- key %s line %s:%d -->\n", stickyKey, path, line)
+}
+
+func reviewKeyPrefix(stickyKey string) string {
+	return fmt.Sprintf("\n<!-- key %s line ", stickyKey)
+}
+
+// SubmitReview posts comments as a single PR review. Comments on lines outside the PR diff are dropped and comments on the same line are joined.
+//
+// Comments from a previous review with the same key are matched by line: they are edited if the body changed,
+// their thread is resolved if the line no longer has a comment, and reopened if it has one again.
+// No review is posted if there are no new comments.
+//
+// github.repository => repo (alexgartner-bc/my-repo)
+// github.event.issue.number => number
+// sha is the commit the lines refer to (optional, defaults to the head of the PR)
+func SubmitReview(ctx context.Context, repo string, number int, sha string, summary string, comments []ReviewComment, stickyKey string) (*ReviewResult, error) {
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	diffLines, err := listDiffLines(ctx, client, owner, name, number)
+	if err != nil {
+		return nil, err
+	}
+	existingComments, err := listReviewComments(ctx, client, owner, name, number, stickyKey)
+	if err != nil {
+		return nil, err
+	}
+	plan := planReview(comments, existingComments, diffLines, stickyKey)
+
+	var threads map[int64]reviewThread
+	if len(existingComments) > 0 {
+		threads, err = listReviewThreads(ctx, client, owner, name, number)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := &ReviewResult{
+		Created:     len(plan.create),
+		Unchanged:   len(plan.unchanged),
+		OutsideDiff: plan.outsideDiff,
+	}
+	for _, comment := range plan.update {
+		_, _, err = client.PullRequests.EditComment(ctx, owner, name, comment.GetID(), &github.PullRequestComment{
+			Body: comment.Body,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to edit review comment: %w", err)
+		}
+		res.Updated++
+	}
+	for _, comment := range append(plan.update, plan.unchanged...) {
+		if thread, ok := threads[comment.GetID()]; ok && thread.IsResolved {
+			err = setReviewThreadResolved(ctx, client, thread.ID, false)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, comment := range plan.gone {
+		if thread, ok := threads[comment.GetID()]; ok && !thread.IsResolved {
+			err = setReviewThreadResolved(ctx, client, thread.ID, true)
+			if err != nil {
+				return nil, err
+			}
+			res.Resolved++
+		}
+	}
+
+	if len(plan.create) == 0 {
+		return res, nil
+	}
+	review := &github.PullRequestReviewRequest{
+		Event:    github.String("COMMENT"),
+		Comments: plan.create,
+	}
+	if summary != "" {
+		review.Body = github.String(summary + stickyKeyText(stickyKey))
+	}
+	if sha != "" {
+		review.CommitID = &sha
+	}
+	_, _, err = client.PullRequests.CreateReview(ctx, owner, name, number, review)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create review: %w", err)
+	}
+	return res, nil
+}
+
+type reviewPlan struct {
+	create []*github.DraftReviewComment
+	// update has the new body set
+	update      []*github.PullRequestComment
+	unchanged   []*github.PullRequestComment
+	gone        []*github.PullRequestComment
+	outsideDiff int
+}
+
+// planReview matches comments to the existing comments (newest first) from a previous review by line
+func planReview(comments []ReviewComment, existingComments []*github.PullRequestComment, diffLines map[string]map[int]bool, stickyKey string) *reviewPlan {
+	plan := &reviewPlan{}
+
+	var keys []string
+	bodies := make(map[string]string)
+	locations := make(map[string]ReviewComment)
+	for _, comment := range comments {
+		if !diffLines[comment.Path][comment.Line] {
+			plan.outsideDiff++
+			continue
+		}
+		keyText := reviewLineKeyText(stickyKey, comment.Path, comment.Line)
+		if body, ok := bodies[keyText]; ok {
+			bodies[keyText] = body + "\n\n" + comment.Body
+			continue
+		}
+		keys = append(keys, keyText)
+		bodies[keyText] = comment.Body
+		locations[keyText] = comment
+	}
+
+	existingByKey := make(map[string]*github.PullRequestComment)
+	for _, comment := range existingComments {
+		keyText := reviewLineKeyRe.FindString(comment.GetBody())
+		if _, ok := existingByKey[keyText]; ok {
+			// only the newest comment for a line is kept up to date
+			continue
+		}
+		existingByKey[keyText] = comment
+	}
+
+	for _, keyText := range keys {
+		body := bodies[keyText] + keyText
+		existing, ok := existingByKey[keyText]
+		if !ok {
+			location := locations[keyText]
+			plan.create = append(plan.create, &github.DraftReviewComment{
+				Path: github.String(location.Path),
+				Line: github.Int(location.Line),
+				Side: github.String("RIGHT"),
+				Body: github.String(body),
+			})
+			continue
+		}
+		delete(existingByKey, keyText)
+		if strings.ReplaceAll(existing.GetBody(), "\r\n", "\n") == body {
+			plan.unchanged = append(plan.unchanged, existing)
+			continue
+		}
+		updated := *existing
+		updated.Body = github.String(body)
+		plan.update = append(plan.update, &updated)
+	}
+
+	for _, comment := range existingComments {
+		if existingByKey[reviewLineKeyRe.FindString(comment.GetBody())] == comment {
+			plan.gone = append(plan.gone, comment)
+		}
+	}
+	return plan
+}
+
+var reviewLineKeyRe = regexp.MustCompile(`\n<!-- key .* line .*:\d+ -->\n`)
+
+// listReviewComments walks every page of review comments on a PR and returns the top level ones tagged with stickyKey, newest first
+func listReviewComments(ctx context.Context, client *github.Client, owner string, name string, number int, stickyKey string) ([]*github.PullRequestComment, error) {
+	opts := &github.PullRequestListCommentsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	var res []*github.PullRequestComment
+	for {
+		comments, resp, err := client.PullRequests.ListComments(ctx, owner, name, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list review comments: %w", err)
+		}
+		for _, comment := range comments {
+			if comment.InReplyTo == nil && strings.Contains(comment.GetBody(), reviewKeyPrefix(stickyKey)) {
+				res = append(res, comment)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return newerThan(res[i].GetCreatedAt(), res[i].GetID(), res[j].GetCreatedAt(), res[j].GetID())
+	})
+	return res, nil
+}
+
+// listDiffLines returns the lines of each file in a PR which can be commented on (added and context lines of the new version)
+func listDiffLines(ctx context.Context, client *github.Client, owner string, name string, number int) (map[string]map[int]bool, error) {
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+	res := make(map[string]map[int]bool)
+	for {
+		files, resp, err := client.PullRequests.ListFiles(ctx, owner, name, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list PR files: %w", err)
+		}
+		for _, file := range files {
+			res[file.GetFilename()] = patchLines(file.GetPatch())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return res, nil
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// patchLines returns the line numbers of the new file which are in a unified diff patch
+func patchLines(patch string) map[int]bool {
+	res := make(map[int]bool)
+	line := 0
+	for _, text := range strings.Split(patch, "\n") {
+		if matches := hunkHeaderRe.FindStringSubmatch(text); matches != nil {
+			line, _ = strconv.Atoi(matches[1])
+			continue
+		}
+		if line == 0 || text == "" {
+			continue
+		}
+		switch text[0] {
+		case '+', ' ':
+			res[line] = true
+			line++
+		}
+	}
+	return res
+}
+
+type reviewThread struct {
+	ID         string `json:"id"`
+	IsResolved bool   `json:"isResolved"`
+}
+
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        nodes {
+          id
+          isResolved
+          comments(first: 1) { nodes { databaseId } }
+        }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}`
+
+// listReviewThreads returns the review threads of a PR by the id of their first comment
+func listReviewThreads(ctx context.Context, client *github.Client, owner string, name string, number int) (map[int64]reviewThread, error) {
+	res := make(map[int64]reviewThread)
+	var cursor *string
+	for {
+		data := struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads struct {
+						Nodes []struct {
+							reviewThread
+							Comments struct {
+								Nodes []struct {
+									DatabaseID int64 `json:"databaseId"`
+								} `json:"nodes"`
+							} `json:"comments"`
+						} `json:"nodes"`
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}{}
+		err := graphQL(ctx, client, reviewThreadsQuery, map[string]interface{}{
+			"owner":  owner,
+			"name":   name,
+			"number": number,
+			"cursor": cursor,
+		}, &data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list review threads: %w", err)
+		}
+		threads := data.Repository.PullRequest.ReviewThreads
+		for _, node := range threads.Nodes {
+			if len(node.Comments.Nodes) > 0 {
+				res[node.Comments.Nodes[0].DatabaseID] = node.reviewThread
+			}
+		}
+		if !threads.PageInfo.HasNextPage {
+			break
+		}
+		cursor = &threads.PageInfo.EndCursor
+	}
+	return res, nil
+}
+
+const resolveReviewThreadMutation = `mutation($id: ID!) {
+  resolveReviewThread(input: {threadId: $id}) { thread { isResolved } }
+}`
+
+const unresolveReviewThreadMutation = `mutation($id: ID!) {
+  unresolveReviewThread(input: {threadId: $id}) { thread { isResolved } }
+}`
+
+func setReviewThreadResolved(ctx context.Context, client *github.Client, threadID string, resolved bool) error {
+	mutation := unresolveReviewThreadMutation
+	if resolved {
+		mutation = resolveReviewThreadMutation
+	}
+	err := graphQL(ctx, client, mutation, map[string]interface{}{"id": threadID}, nil)
+	if err != nil {
+		return fmt.Errorf("unable to set review thread resolved=%t: %w", resolved, err)
+	}
+	return nil
+}
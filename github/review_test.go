@@ -0,0 +1,47 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchLines(t *testing.T) {
+	patch := "@@ -1,3 +1,4 @@\n package main\n-import \"os\"\n+import \"fmt\"\n+import \"os\"\n \n@@ -20,2 +21,2 @@ func main() {\n-\tos.Exit(1)\n+\tos.Exit(2)\n }\n\\ No newline at end of file"
+	require.Equal(t, map[int]bool{1: true, 2: true, 3: true, 4: true, 21: true, 22: true}, patchLines(patch))
+	require.Empty(t, patchLines(""))
+}
+
+func TestPlanReview(t *testing.T) {
+	key := "lint"
+	diffLines := map[string]map[int]bool{
+		"main.go": {1: true, 2: true, 3: true, 4: true},
+	}
+	existing := []*github.PullRequestComment{
+		{ID: github.Int64(1), Body: github.String("unused import" + reviewLineKeyText(key, "main.go", 1))},
+		{ID: github.Int64(2), Body: github.String("old message" + reviewLineKeyText(key, "main.go", 2))},
+		{ID: github.Int64(3), Body: github.String("fixed" + reviewLineKeyText(key, "main.go", 3))},
+	}
+	plan := planReview([]ReviewComment{
+		{Path: "main.go", Line: 1, Body: "unused import"},
+		{Path: "main.go", Line: 2, Body: "new message"},
+		{Path: "main.go", Line: 4, Body: "first"},
+		{Path: "main.go", Line: 4, Body: "second"},
+		{Path: "main.go", Line: 40, Body: "not in the diff"},
+		{Path: "other.go", Line: 1, Body: "not in the diff"},
+	}, existing, diffLines, key)
+
+	require.Len(t, plan.create, 1)
+	require.Equal(t, "main.go", plan.create[0].GetPath())
+	require.Equal(t, 4, plan.create[0].GetLine())
+	require.Equal(t, "first\n\nsecond"+reviewLineKeyText(key, "main.go", 4), plan.create[0].GetBody())
+
+	require.Len(t, plan.update, 1)
+	require.Equal(t, int64(2), plan.update[0].GetID())
+	require.Equal(t, "new message"+reviewLineKeyText(key, "main.go", 2), plan.update[0].GetBody())
+
+	require.Equal(t, []*github.PullRequestComment{existing[0]}, plan.unchanged)
+	require.Equal(t, []*github.PullRequestComment{existing[2]}, plan.gone)
+	require.Equal(t, 2, plan.outsideDiff)
+}
@@ -0,0 +1,71 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// commit status states accepted by github
+const (
+	StatusPending = "pending"
+	StatusSuccess = "success"
+	StatusError   = "error"
+	StatusFailure = "failure"
+)
+
+// github rejects status descriptions longer than this
+const maxStatusDescriptionLength = 140
+
+// SetCommitStatus sets a (legacy) commit status on a sha. A status replaces any previous status with the same context.
+//
+// github.repository => repo (alexgartner-bc/my-repo)
+// github.sha => sha
+func SetCommitStatus(ctx context.Context, repo string, sha string, state string, statusContext string, description string, targetURL string) error {
+	switch state {
+	case StatusPending, StatusSuccess, StatusError, StatusFailure:
+	default:
+		return fmt.Errorf("invalid status state %q (pending, success, error, failure)", state)
+	}
+	if statusContext == "" {
+		return fmt.Errorf("status context must be set")
+	}
+
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	status := &github.RepoStatus{
+		State:   &state,
+		Context: &statusContext,
+	}
+	if description != "" {
+		description = truncateStatusDescription(description)
+		status.Description = &description
+	}
+	if targetURL != "" {
+		status.TargetURL = &targetURL
+	}
+
+	_, _, err = client.Repositories.CreateStatus(ctx, owner, name, sha, status)
+	if err != nil {
+		return fmt.Errorf("unable to create status: %w", err)
+	}
+	return nil
+}
+
+func truncateStatusDescription(description string) string {
+	if utf8.RuneCountInString(description) <= maxStatusDescriptionLength {
+		return description
+	}
+	runes := []rune(description)
+	return string(runes[:maxStatusDescriptionLength-1]) + "…"
+}
@@ -3,26 +3,165 @@ package github
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
+	"github.com/google/go-github/v45/github"
 )
 
+const trailerAttempts = 5
+
+// TrailerSettleDelay is how long to wait before checking our edit wasn't overwritten by a concurrent writer.
+// It needs to be longer than the time between another writer reading the PR and writing it back. 0 skips the check.
+var TrailerSettleDelay = 500 * time.Millisecond
+
 // SetPRTrailerDetails update the a PR and sets some text at the bottom. Might be better than a comment because it doesn't cause a notification.
 // If summary is set, use <details>. Else use <span>
 //
+// Editing the body is a read-modify-write, so after editing the PR is fetched again to check the trailer survived
+// concurrent edits (other jobs setting other keys, the author editing the description). If it didn't the edit is retried.
+//
 // github.repository => repo (alexgartner-bc/my-repo)
 // github.event.issue.number => number
 func SetPRTrailerDetails(ctx context.Context, repo string, number int, summary string, details string, stickyKey string) error {
-	client := getDefaultClient()
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	var block string
+	err = editPRBody(ctx, client, owner, name, number,
+		func(body string) (string, error) {
+			var newBody string
+			var err error
+			newBody, block, err = mergeTrailerWithLimit(body, summary, details, stickyKey)
+			return newBody, err
+		},
+		func(body string) bool {
+			return strings.Contains(body, block)
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to set PR trailer %s: %w", stickyKey, err)
+	}
+	return nil
+}
+
+// editPRBody does a read-modify-write of the PR body with edit, see EditDescription
+func editPRBody(ctx context.Context, client *github.Client, owner string, name string, number int, edit func(body string) (string, error), check func(body string) bool) error {
+	return EditDescription(ctx,
+		func() (string, error) {
+			return getPRBody(ctx, client, owner, name, number)
+		},
+		func(body string) error {
+			_, _, err := client.PullRequests.Edit(ctx, owner, name, number, &github.PullRequest{
+				Body: &body,
+			})
+			if err != nil {
+				return fmt.Errorf("unable to edit PR: %w", err)
+			}
+			return nil
+		},
+		edit,
+		check,
+	)
+}
+
+// EditDescription does a read-modify-write of a PR description (or the equivalent on other scms) with edit.
+// Once written the description is read again after TrailerSettleDelay and check confirms the edit survived
+// concurrent writers, else the edit is retried on the new description. get returns the description with line endings normalized.
+func EditDescription(ctx context.Context, get func() (string, error), put func(body string) error, edit func(body string) (string, error), check func(body string) bool) error {
+	jitter := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for attempt := 0; attempt < trailerAttempts; attempt++ {
+		if attempt > 0 && TrailerSettleDelay > 0 {
+			// spread out writers which collided
+			time.Sleep(time.Duration(jitter.Int63n(int64(TrailerSettleDelay))))
+		}
+
+		body, err := get()
+		if err != nil {
+			return err
+		}
 
-	repoParts := strings.Split(repo, "/")
+		newBody, err := edit(body)
+		if err != nil {
+			return err
+		}
+		if newBody == body {
+			return nil
+		}
 
+		// make the window for a lost update as small as possible
+		currentBody, err := get()
+		if err != nil {
+			return err
+		}
+		if currentBody != body {
+			continue
+		}
+
+		err = put(newBody)
+		if err != nil {
+			return err
+		}
+		if dryrun.Enabled || TrailerSettleDelay == 0 {
+			// the edit wasn't made or isn't verified
+			return nil
+		}
+
+		time.Sleep(TrailerSettleDelay)
+		currentBody, err = get()
+		if err != nil {
+			return err
+		}
+		if check(currentBody) {
+			return nil
+		}
+	}
+	return fmt.Errorf("overwritten by concurrent edits %d times", trailerAttempts)
+}
+
+// getPRBody returns the body of a PR with line endings normalized (edits in the web ui use \r\n)
+func getPRBody(ctx context.Context, client *github.Client, owner string, name string, number int) (string, error) {
+	pr, _, err := client.PullRequests.Get(ctx, owner, name, number)
+	if err != nil {
+		return "", fmt.Errorf("unable to get PR: %w", err)
+	}
+	return strings.ReplaceAll(pr.GetBody(), "\r\n", "\n"), nil
+}
+
+const truncatedTrailerNote = "\n_… %d more lines truncated (github PR body size limit)_\n"
+
+// mergeTrailerWithLimit is mergeTrailer with details truncated to keep the body under MaxBodyLength
+func mergeTrailerWithLimit(body string, summary string, details string, stickyKey string) (string, string, error) {
+	newBody, block := mergeTrailer(body, summary, details, stickyKey)
+	if overflow := len(newBody) - MaxBodyLength; overflow > 0 {
+		truncated := TruncateMarkdown(details, len(details)-overflow, truncatedTrailerNote)
+		if truncated == "" {
+			return "", "", fmt.Errorf("PR body is too large to add a trailer (%d > %d)", len(newBody), MaxBodyLength)
+		}
+		newBody, block = mergeTrailer(body, summary, truncated, stickyKey)
+	}
+	return newBody, block, nil
+}
+
+// mergeTrailer replaces the trailer tagged with stickyKey in body, or appends it. It returns the new body and the trailer block.
+func mergeTrailer(body string, summary string, details string, stickyKey string) (string, string) {
 	tag := "span"
 	if summary != "" {
 		tag = "details"
 	}
 
-	openingTag := fmt.Sprintf("<%s id=\"%s\">", tag, stickyKey)
+	openingTag := trailerOpeningTag(tag, stickyKey)
 	closingTag := fmt.Sprintf("</%s>", tag)
 
 	summaryTag := ""
@@ -32,29 +171,134 @@ func SetPRTrailerDetails(ctx context.Context, repo string, number int, summary s
 
 	text := fmt.Sprintf("\n%s%s\n\n%s\n\n%s", openingTag, summaryTag, details, closingTag)
 
-	pr, _, err := client.PullRequests.Get(ctx, repoParts[0], repoParts[1], number)
+	if strings.Contains(body, openingTag) {
+		return trailerRegexp(tag, stickyKey).ReplaceAllLiteralString(body, text), text
+	}
+	return body + text, text
+}
+
+// MergeTrailer replaces the trailer tagged with stickyKey in a description, or appends it, without a size limit.
+// It returns the new body and the trailer block. For other scms using the same trailer format.
+func MergeTrailer(body string, summary string, details string, stickyKey string) (string, string) {
+	return mergeTrailer(strings.ReplaceAll(body, "\r\n", "\n"), summary, details, stickyKey)
+}
+
+var trailerTags = []string{"details", "span"}
+
+func trailerOpeningTag(tag string, stickyKey string) string {
+	return fmt.Sprintf("<%s id=\"%s\">", tag, stickyKey)
+}
+
+// trailerRegexp matches a whole trailer block. stickyKey can be "" to match any key.
+// The closing tag is always preceded by a blank line, which keeps <details> nested in the trailer from ending it early.
+func trailerRegexp(tag string, stickyKey string) *regexp.Regexp {
+	id := regexp.QuoteMeta(stickyKey)
+	if stickyKey == "" {
+		id = `([^"]*)`
+	}
+	return regexp.MustCompile(fmt.Sprintf(`(?ms)\n?<%s id="%s">(?:<summary>(.*?)</summary>)?.*?\n\n</%s>`, tag, id, tag))
+}
+
+// Trailer is a block added to a PR body by SetPRTrailerDetails
+type Trailer struct {
+	Key string
+	// Summary is empty for trailers added without one (<span>)
+	Summary string
+}
+
+// ParseTrailers returns the trailers in a PR body in the order they appear
+func ParseTrailers(body string) []Trailer {
+	type found struct {
+		index   int
+		trailer Trailer
+	}
+	var all []found
+	for _, tag := range trailerTags {
+		for _, match := range trailerRegexp(tag, "").FindAllStringSubmatchIndex(body, -1) {
+			trailer := Trailer{
+				Key: body[match[2]:match[3]],
+			}
+			if match[4] >= 0 {
+				trailer.Summary = body[match[4]:match[5]]
+			}
+			all = append(all, found{index: match[0], trailer: trailer})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].index < all[j].index
+	})
+
+	var res []Trailer
+	for _, f := range all {
+		res = append(res, f.trailer)
+	}
+	return res
+}
+
+// removeTrailer removes the trailer tagged with stickyKey from body
+func removeTrailer(body string, stickyKey string) string {
+	for _, tag := range trailerTags {
+		body = trailerRegexp(tag, stickyKey).ReplaceAllLiteralString(body, "")
+	}
+	return body
+}
+
+func hasTrailer(body string, stickyKey string) bool {
+	for _, tag := range trailerTags {
+		if strings.Contains(body, trailerOpeningTag(tag, stickyKey)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListPRTrailers returns the trailers added to a PR by SetPRTrailerDetails
+//
+// github.repository => repo (alexgartner-bc/my-repo)
+// github.event.issue.number => number
+func ListPRTrailers(ctx context.Context, repo string, number int) ([]Trailer, error) {
+	client, err := getDefaultClient(repo)
 	if err != nil {
-		return fmt.Errorf("unable to get PR: %w", err)
+		return nil, err
 	}
-	pr.MaintainerCanModify = nil
 
-	body := ""
-	if pr.Body != nil {
-		body = *pr.Body
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return nil, err
 	}
 
-	if strings.Contains(body, openingTag) {
-		re := regexp.MustCompile(fmt.Sprintf("(?ms)\n%s.+?%s", openingTag, closingTag))
-		body = re.ReplaceAllString(body, text)
-	} else {
-		body += text
+	body, err := getPRBody(ctx, client, owner, name, number)
+	if err != nil {
+		return nil, err
 	}
+	return ParseTrailers(body), nil
+}
 
-	pr.Body = &body
+// RemovePRTrailer removes the trailer tagged with stickyKey from a PR. It is not an error if there is none.
+//
+// github.repository => repo (alexgartner-bc/my-repo)
+// github.event.issue.number => number
+func RemovePRTrailer(ctx context.Context, repo string, number int, stickyKey string) error {
+	client, err := getDefaultClient(repo)
+	if err != nil {
+		return err
+	}
+
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return err
+	}
 
-	_, _, err = client.PullRequests.Edit(ctx, repoParts[0], repoParts[1], number, pr)
+	err = editPRBody(ctx, client, owner, name, number,
+		func(body string) (string, error) {
+			return removeTrailer(body, stickyKey), nil
+		},
+		func(body string) bool {
+			return !hasTrailer(body, stickyKey)
+		},
+	)
 	if err != nil {
-		return fmt.Errorf("unable to edit PR: %w", err)
+		return fmt.Errorf("unable to remove PR trailer %s: %w", stickyKey, err)
 	}
 	return nil
 }
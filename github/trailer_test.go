@@ -0,0 +1,125 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeTrailer(t *testing.T) {
+	body, block := mergeTrailer("description", "summary", "details", "key")
+	require.Equal(t, "description\n<details id=\"key\"><summary>summary</summary>\n\ndetails\n\n</details>", body)
+	require.Equal(t, "\n<details id=\"key\"><summary>summary</summary>\n\ndetails\n\n</details>", block)
+
+	body, _ = mergeTrailer(body, "new summary", "new $1 details", "key")
+	require.Equal(t, "description\n<details id=\"key\"><summary>new summary</summary>\n\nnew $1 details\n\n</details>", body)
+
+	body, _ = mergeTrailer(body, "", "span", "other.key")
+	require.Equal(t, "description\n<details id=\"key\"><summary>new summary</summary>\n\nnew $1 details\n\n</details>\n<span id=\"other.key\">\n\nspan\n\n</span>", body)
+}
+
+func TestSetPRTrailerDetailsConcurrent(t *testing.T) {
+	fake := newFakeGithub(t)
+	fake.prBodies[1] = "description"
+	// another job read the PR before our edit and writes its trailer back after it, dropping ours
+	fake.afterEdit = func(number int) {
+		if fake.edits == 1 {
+			fake.prBodies[number], _ = mergeTrailer("description", "summary", "job 1", "key-1")
+		}
+	}
+
+	err := SetPRTrailerDetails(context.Background(), "owner/repo", 1, "summary", "job 0", "key-0")
+	require.NoError(t, err)
+	body := fake.body(1)
+	require.Contains(t, body, "description")
+	for i := 0; i < 2; i++ {
+		require.Contains(t, body, fmt.Sprintf("<details id=\"key-%d\"><summary>summary</summary>\n\njob %d\n\n</details>", i, i))
+	}
+	require.Equal(t, 2, fake.edits)
+}
+
+func TestSetPRTrailerDetailsNoSettle(t *testing.T) {
+	fake := newFakeGithub(t)
+	TrailerSettleDelay = 0
+	fake.prBodies[1] = "description"
+	fake.afterEdit = func(number int) {
+		fake.prBodies[number] = "edited description"
+	}
+
+	// without a settle delay the edit isn't verified
+	err := SetPRTrailerDetails(context.Background(), "owner/repo", 1, "summary", "details", "key")
+	require.NoError(t, err)
+	require.Equal(t, "edited description", fake.body(1))
+	require.Equal(t, 1, fake.edits)
+}
+
+func TestSetPRTrailerDetailsAuthorEdit(t *testing.T) {
+	fake := newFakeGithub(t)
+	fake.prBodies[1] = "description"
+	// the author saves a description they loaded before our edit, dropping the trailer
+	fake.afterEdit = func(number int) {
+		if fake.edits == 1 {
+			fake.prBodies[number] = "edited description"
+		}
+	}
+
+	err := SetPRTrailerDetails(context.Background(), "owner/repo", 1, "summary", "details", "key")
+	require.NoError(t, err)
+	require.Equal(t, "edited description\n<details id=\"key\"><summary>summary</summary>\n\ndetails\n\n</details>", fake.body(1))
+	require.Equal(t, 2, fake.edits)
+}
+
+func TestParseAndRemoveTrailers(t *testing.T) {
+	body := "description"
+	body, _ = mergeTrailer(body, "tests", "<details><summary>TestA</summary>\nfailed\n</details>\n", "gotest")
+	body, _ = mergeTrailer(body, "", "plain", "span-key")
+	body, _ = mergeTrailer(body, "pulumi (create 1)", "tree", "pulumi")
+
+	require.Equal(t, []Trailer{
+		{Key: "gotest", Summary: "tests"},
+		{Key: "span-key"},
+		{Key: "pulumi", Summary: "pulumi (create 1)"},
+	}, ParseTrailers(body))
+
+	body = removeTrailer(body, "gotest")
+	require.False(t, hasTrailer(body, "gotest"))
+	require.NotContains(t, body, "TestA")
+	body = removeTrailer(body, "span-key")
+	require.Equal(t, "description\n<details id=\"pulumi\"><summary>pulumi (create 1)</summary>\n\ntree\n\n</details>", body)
+	require.Equal(t, body, removeTrailer(body, "missing"))
+}
+
+func TestRemovePRTrailer(t *testing.T) {
+	fake := newFakeGithub(t)
+	fake.prBodies[1], _ = mergeTrailer("description", "summary", "details", "key")
+
+	trailers, err := ListPRTrailers(context.Background(), "owner/repo", 1)
+	require.NoError(t, err)
+	require.Equal(t, []Trailer{{Key: "key", Summary: "summary"}}, trailers)
+
+	err = RemovePRTrailer(context.Background(), "owner/repo", 1, "key")
+	require.NoError(t, err)
+	require.Equal(t, "description", fake.body(1))
+}
+
+func TestSetPRTrailerDetailsDryRun(t *testing.T) {
+	fake := newFakeGithub(t)
+	fake.prBodies[1] = "description"
+	var out bytes.Buffer
+	dryrun.Enabled, dryrun.Output = true, &out
+	t.Cleanup(func() {
+		dryrun.Enabled, dryrun.Output = false, os.Stderr
+	})
+
+	err := SetPRTrailerDetails(context.Background(), "owner/repo", 1, "summary", "details", "key")
+	require.NoError(t, err)
+	require.Equal(t, "description", fake.body(1))
+	require.Equal(t, 0, fake.edits)
+	require.Contains(t, out.String(), "dry-run: PATCH ")
+	require.Contains(t, out.String(), "description\n<details id=\"key\"><summary>summary</summary>\n\ndetails\n\n</details>")
+}
@@ -0,0 +1,241 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Debug logs every github api call and the remaining quota to stderr
+var Debug = false
+
+const (
+	maxRetries = 5
+	// don't wait longer than this for a rate limit to reset
+	maxRetryWait = 10 * time.Minute
+	maxBackoff   = time.Minute
+)
+
+// sleep waits for d or until ctx is done. A var so tests don't have to wait.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type cachedResponse struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// cacheablePathRegex matches the list endpoints the package walks repeatedly (comments, labels, files...).
+// Single resources like a PR are always fetched fresh, they are read-modify-written.
+var cacheablePathRegex = regexp.MustCompile(`/(comments|labels|pulls|files|releases)$`)
+
+// retryTransport retries requests which failed because of rate limits or server errors and makes GET requests
+// to list endpoints conditional with ETags (304s don't count against the rate limit).
+// Each client has its own transport so cached responses are never shared between credentials.
+type retryTransport struct {
+	base http.RoundTripper
+
+	mu     sync.Mutex
+	cache  map[string]*cachedResponse
+	jitter *rand.Rand
+}
+
+func newRetryTransport(base http.RoundTripper) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{
+		base:   base,
+		cache:  make(map[string]*cachedResponse),
+		jitter: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	// the same url returns different bodies for different media types
+	cacheKey := req.Header.Get("Accept") + " " + req.URL.String()
+	cacheable := req.Method == http.MethodGet && cacheablePathRegex.MatchString(req.URL.Path)
+
+	var cached *cachedResponse
+	if cacheable {
+		t.mu.Lock()
+		cached = t.cache[cacheKey]
+		t.mu.Unlock()
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req.Clone(ctx)
+		if req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+		if cached != nil {
+			attemptReq.Header.Set("If-None-Match", cached.etag)
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err == nil {
+			logResponse(attemptReq, resp)
+		}
+
+		canReplay := req.Body == nil || req.GetBody != nil
+		wait, retry := t.retryAfter(req, resp, err, attempt)
+		if !retry || !canReplay || attempt >= maxRetries {
+			if err != nil {
+				return nil, err
+			}
+			if !cacheable {
+				return resp, nil
+			}
+			return t.handleCache(resp, cacheKey, cached)
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if Debug {
+			fmt.Fprintf(os.Stderr, "github: retrying %s %s in %s\n", req.Method, req.URL.Path, wait.Round(time.Millisecond))
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryAfter decides if a request should be retried and how long to wait first
+func (t *retryTransport) retryAfter(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if err != nil {
+		// the request might have made it, don't risk doing a POST twice
+		return t.backoff(attempt), req.Method != http.MethodPost
+	}
+
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) {
+		seconds, err := strconv.Atoi(retryAfter)
+		if err == nil {
+			wait := time.Duration(seconds) * time.Second
+			return wait, wait <= maxRetryWait
+		}
+	}
+
+	if (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err == nil {
+			wait := time.Until(time.Unix(reset, 0)) + time.Second
+			if wait < 0 {
+				wait = time.Second
+			}
+			return wait, wait <= maxRetryWait
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode == http.StatusForbidden && isSecondaryRateLimit(resp)) {
+		// secondary rate limits without a Retry-After: github recommends waiting at least a minute
+		return time.Minute + t.backoff(attempt), true
+	}
+
+	if resp.StatusCode >= 500 && req.Method != http.MethodPost {
+		return t.backoff(attempt), true
+	}
+	return 0, false
+}
+
+// backoff is exponential with jitter: ~1s, 2s, 4s...
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	wait := time.Second << attempt
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return wait/2 + time.Duration(t.jitter.Int63n(int64(wait/2)+1))
+}
+
+// isSecondaryRateLimit peeks at the body of a 403 for the secondary rate limit message, leaving the body readable
+func isSecondaryRateLimit(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	lower := strings.ToLower(string(body))
+	return strings.Contains(lower, "secondary rate limit") || strings.Contains(lower, "abuse detection")
+}
+
+// handleCache serves 304s from the cache and stores responses with an ETag
+func (t *retryTransport) handleCache(resp *http.Response, cacheKey string, cached *cachedResponse) (*http.Response, error) {
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		header := cached.header.Clone()
+		// keep the fresh rate limit headers
+		for k, v := range resp.Header {
+			if strings.HasPrefix(k, "X-Ratelimit-") {
+				header[k] = v
+			}
+		}
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.Header = header
+		resp.Body = io.NopCloser(bytes.NewReader(cached.body))
+		resp.ContentLength = int64(len(cached.body))
+		return resp, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.mu.Lock()
+	t.cache[cacheKey] = &cachedResponse{
+		etag:   etag,
+		header: resp.Header.Clone(),
+		body:   body,
+	}
+	t.mu.Unlock()
+	return resp, nil
+}
+
+func logResponse(req *http.Request, resp *http.Response) {
+	if !Debug {
+		return
+	}
+	quota := ""
+	if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+		quota = fmt.Sprintf(" (quota %s/%s", remaining, resp.Header.Get("X-RateLimit-Limit"))
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			quota += fmt.Sprintf(", resets %s", time.Unix(reset, 0).Format(time.Kitchen))
+		}
+		quota += ")"
+	}
+	fmt.Fprintf(os.Stderr, "github: %s %s %d%s\n", req.Method, req.URL.Path, resp.StatusCode, quota)
+}
@@ -0,0 +1,129 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordSleeps replaces sleep for the duration of the test
+func recordSleeps(t *testing.T) *[]time.Duration {
+	var sleeps []time.Duration
+	oldSleep := sleep
+	sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	t.Cleanup(func() {
+		sleep = oldSleep
+	})
+	return &sleeps
+}
+
+func TestRetryTransportServerError(t *testing.T) {
+	sleeps := recordSleeps(t)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		require.Equal(t, "payload", string(body))
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newRetryTransport(nil)}
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	// POSTs aren't retried on server errors, they might have gone through
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodPatch, srv.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 3, requests)
+	require.Len(t, *sleeps, 1)
+}
+
+func TestRetryTransportRateLimit(t *testing.T) {
+	sleeps := recordSleeps(t)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit"}`)
+		case 2:
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit"}`)
+		case 3:
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(30*time.Second).Unix()))
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			fmt.Fprint(w, "ok")
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newRetryTransport(nil)}
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, *sleeps, 3)
+	require.Equal(t, 3*time.Second, (*sleeps)[0])
+	require.GreaterOrEqual(t, (*sleeps)[1], time.Minute)
+	require.InDelta(t, float64(31*time.Second), float64((*sleeps)[2]), float64(2*time.Second))
+}
+
+func TestRetryTransportETag(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		etag := `"` + r.Header.Get("Accept") + r.URL.Path + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, "%s %s", r.URL.Path, r.Header.Get("Accept"))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newRetryTransport(nil)}
+	get := func(path string, accept string) string {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", accept)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	for i := 0; i < 2; i++ {
+		require.Equal(t, "/repos/o/r/issues/1/comments json", get("/repos/o/r/issues/1/comments", "json"))
+		// another media type of the same url isn't served from the cache
+		require.Equal(t, "/repos/o/r/issues/1/comments raw", get("/repos/o/r/issues/1/comments", "raw"))
+		// single resources aren't conditional
+		require.Equal(t, "/repos/o/r/pulls/1 json", get("/repos/o/r/pulls/1", "json"))
+	}
+	require.Equal(t, 6, requests)
+	require.Len(t, client.Transport.(*retryTransport).cache, 2)
+}
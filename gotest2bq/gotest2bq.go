@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"cloud.google.com/go/bigquery"
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
 	"github.com/google/uuid"
 )
 
@@ -76,6 +77,9 @@ func GoTest2BQ(args GoTest2BQArgs) error {
 	if err != nil {
 		return fmt.Errorf("load test log: %w", err)
 	}
+	if dryrun.Enabled {
+		return printTestEvents(args, testEvents)
+	}
 	client, err := bigquery.NewClient(ctx, args.Project)
 	if err != nil {
 		return fmt.Errorf("bigquery client: %w", err)
@@ -115,3 +119,25 @@ func GoTest2BQ(args GoTest2BQArgs) error {
 
 	return nil
 }
+
+// printTestEvents prints the rows which would be inserted, as the inserter saves them (bigquery column names, no Output)
+func printTestEvents(args GoTest2BQArgs, testEvents []*TestEvent) error {
+	schema, err := bigquery.InferSchema(TestEvent{})
+	if err != nil {
+		return fmt.Errorf("infer schema: %w", err)
+	}
+	dryrun.Printf("insert %d rows into %s.%s.%s\n", len(testEvents), args.Project, args.Dataset, args.Table)
+	enc := json.NewEncoder(dryrun.Output)
+	for _, event := range testEvents {
+		saver := &bigquery.StructSaver{Struct: event, Schema: schema}
+		row, _, err := saver.Save()
+		if err != nil {
+			return err
+		}
+		err = enc.Encode(row)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
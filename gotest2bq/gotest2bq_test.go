@@ -0,0 +1,34 @@
+package gotest2bq
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintTestEvents(t *testing.T) {
+	var out bytes.Buffer
+	oldOutput := dryrun.Output
+	dryrun.Output = &out
+	t.Cleanup(func() {
+		dryrun.Output = oldOutput
+	})
+
+	err := printTestEvents(GoTest2BQArgs{Project: "p", Dataset: "d", Table: "t"}, []*TestEvent{{
+		Branch:  "main",
+		GroupId: "group",
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Action:  "pass",
+		Package: "example.com/ft",
+		Test:    "TestA",
+		Elapsed: 0.5,
+		Output:  "not inserted",
+	}})
+	require.NoError(t, err)
+	require.Equal(t, `dry-run: insert 1 rows into p.d.t
+{"action":"pass","branch":"main","commit":"","elapsed":0.5,"env":"","group_id":"group","package":"example.com/ft","test":"TestA","time":"2024-01-02T03:04:05Z"}
+`, out.String())
+}
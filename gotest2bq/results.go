@@ -0,0 +1,176 @@
+package gotest2bq
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TestResults is the final outcome of every test in a go test log
+type TestResults struct {
+	Passed  []string
+	Failed  []string
+	Skipped []string
+	// Flaky tests failed and then passed when retried (go test -count, gotestsum --rerun-fails). They are also in Passed.
+	Flaky    []string
+	Failures []*TestFailure
+}
+
+// TestFailure is a failed test (or package when Test is empty) and what it printed
+type TestFailure struct {
+	Package string
+	Test    string
+	// File and Line of the last `file_test.go:12:` in the output (if any), usually the failed assertion. File is relative to the package.
+	File   string
+	Line   int
+	Output string
+}
+
+// LoadTestResults reads a `go test -json` log and returns the final outcome of each test.
+// Packages which fail without a failing test (build errors, panics in TestMain) are reported as failed.
+// A test with a failing subtest isn't reported as failed itself, only its failing subtests are.
+func LoadTestResults(filename string) (*TestResults, error) {
+	testEvents, err := loadTestLog(GoTest2BQArgs{Filename: filename})
+	if err != nil {
+		return nil, fmt.Errorf("load test log: %w", err)
+	}
+
+	var order []string
+	outcomes := make(map[string]*TestEvent)
+	outputs := make(map[string]*strings.Builder)
+	packageHasFailedTest := make(map[string]bool)
+	failedOnce := make(map[string]bool)
+	for _, event := range testEvents {
+		name := event.Package
+		if event.Test != "" {
+			name = event.Package + "." + event.Test
+		}
+		if event.Action == "output" {
+			if _, ok := outputs[name]; !ok {
+				outputs[name] = &strings.Builder{}
+			}
+			outputs[name].WriteString(event.Output)
+			continue
+		}
+		if event.Action != "pass" && event.Action != "fail" && event.Action != "skip" {
+			continue
+		}
+		if event.Test != "" && event.Action == "fail" {
+			packageHasFailedTest[event.Package] = true
+			failedOnce[name] = true
+		} else if event.Test == "" && event.Action != "fail" {
+			// passing and skipped packages are already represented by their tests
+			continue
+		}
+		if _, ok := outcomes[name]; !ok {
+			order = append(order, name)
+		}
+		outcomes[name] = event
+	}
+
+	// go fails the parents of a failed subtest too
+	hasFailedSubtest := make(map[string]bool)
+	for _, name := range order {
+		event := outcomes[name]
+		if event.Action != "fail" {
+			continue
+		}
+		for i := strings.LastIndex(event.Test, "/"); i > 0; i = strings.LastIndex(event.Test[:i], "/") {
+			hasFailedSubtest[event.Package+"."+event.Test[:i]] = true
+		}
+	}
+
+	res := &TestResults{}
+	for _, name := range order {
+		event := outcomes[name]
+		switch event.Action {
+		case "pass":
+			res.Passed = append(res.Passed, name)
+			if failedOnce[name] {
+				res.Flaky = append(res.Flaky, name)
+			}
+		case "fail":
+			if (event.Test == "" && packageHasFailedTest[name]) || hasFailedSubtest[name] {
+				continue
+			}
+			res.Failed = append(res.Failed, name)
+			failure := &TestFailure{
+				Package: event.Package,
+				Test:    event.Test,
+			}
+			if output, ok := outputs[name]; ok {
+				failure.Output = output.String()
+			}
+			failure.File, failure.Line = findFileLine(failure.Output)
+			res.Failures = append(res.Failures, failure)
+		case "skip":
+			res.Skipped = append(res.Skipped, name)
+		}
+	}
+	return res, nil
+}
+
+var fileLineRe = regexp.MustCompile(`(?m)^\s*([\w.\-/]+\.go):(\d+):`)
+
+func findFileLine(output string) (string, int) {
+	allMatches := fileLineRe.FindAllStringSubmatch(output, -1)
+	if len(allMatches) == 0 {
+		return "", 0
+	}
+	matches := allMatches[len(allMatches)-1]
+	line, _ := strconv.Atoi(matches[2])
+	return matches[1], line
+}
+
+// RepoPath returns the path of File relative to the module root, given the module path from go.mod
+func (f *TestFailure) RepoPath(modulePath string) string {
+	if f.File == "" {
+		return ""
+	}
+	if modulePath == "" || (f.Package != modulePath && !strings.HasPrefix(f.Package, modulePath+"/")) {
+		return f.File
+	}
+	dir := strings.TrimPrefix(strings.TrimPrefix(f.Package, modulePath), "/")
+	return path.Join(dir, f.File)
+}
+
+// Name returns package.Test (or the package for package failures)
+func (f *TestFailure) Name() string {
+	if f.Test == "" {
+		return f.Package
+	}
+	return f.Package + "." + f.Test
+}
+
+// ShortSummaryString returns short one line summary of the results
+func (r *TestResults) ShortSummaryString() string {
+	var resParts []string
+	if len(r.Failed) != 0 {
+		resParts = append(resParts, fmt.Sprintf("fail %d", len(r.Failed)))
+	}
+	if len(r.Passed) != 0 {
+		resParts = append(resParts, fmt.Sprintf("pass %d", len(r.Passed)))
+	}
+	if len(r.Flaky) != 0 {
+		resParts = append(resParts, fmt.Sprintf("flaky %d", len(r.Flaky)))
+	}
+	if len(r.Skipped) != 0 {
+		resParts = append(resParts, fmt.Sprintf("skip %d", len(r.Skipped)))
+	}
+	if len(resParts) == 0 {
+		return "no tests"
+	}
+	return strings.Join(resParts, " | ")
+}
+
+// Markdown returns the summary and the output of failed tests
+func (r *TestResults) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**go test** (%s)\n", r.ShortSummaryString())
+	for _, failure := range r.Failures {
+		fmt.Fprintf(&sb, "\n<details><summary>❌ %s</summary>\n\n```\n%s```\n</details>\n", failure.Name(), failure.Output)
+	}
+	return sb.String()
+}
@@ -0,0 +1,41 @@
+package gotest2bq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTestResults(t *testing.T) {
+	results, err := LoadTestResults("testdata/fail.json")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"example.com/ft/sub.TestB"}, results.Passed)
+	require.Equal(t, []string{"example.com/ft/sub.TestA"}, results.Failed)
+	require.Len(t, results.Failures, 1)
+	require.Equal(t, "sub/a_test.go", results.Failures[0].RepoPath("example.com/ft"))
+	require.Equal(t, 4, results.Failures[0].Line)
+	require.Equal(t, "fail 1 | pass 1", results.ShortSummaryString())
+}
+
+func TestLoadTestResultsFlaky(t *testing.T) {
+	results, err := LoadTestResults("testdata/flaky.json")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"example.com/ft.TestRetry", "example.com/ft.TestStable"}, results.Passed)
+	require.Equal(t, []string{"example.com/ft.TestRetry"}, results.Flaky)
+	require.Empty(t, results.Failed)
+	require.Equal(t, "pass 2 | flaky 1", results.ShortSummaryString())
+}
+
+func TestLoadTestResultsSubtests(t *testing.T) {
+	results, err := LoadTestResults("testdata/subtests.json")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"example.com/ft/sub.TestTable/ok", "example.com/ft/sub.TestB"}, results.Passed)
+	// the parent only failed because of its subtest
+	require.Equal(t, []string{"example.com/ft/sub.TestTable/bad"}, results.Failed)
+	require.Len(t, results.Failures, 1)
+	require.Equal(t, 8, results.Failures[0].Line)
+	require.Equal(t, "fail 1 | pass 2", results.ShortSummaryString())
+}
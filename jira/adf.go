@@ -0,0 +1,163 @@
+package jira
+
+import (
+	"strings"
+)
+
+// ADFNode is a node of an atlassian document format document, the rich text the jira cloud v3 api uses for descriptions and comments.
+// See https://developer.atlassian.com/cloud/jira/platform/apis/document/structure/
+type ADFNode struct {
+	Type    string                 `json:"type"`
+	Version int                    `json:"version,omitempty"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Content []*ADFNode             `json:"content,omitempty"`
+	Text    string                 `json:"text,omitempty"`
+	Marks   []*ADFMark             `json:"marks,omitempty"`
+}
+
+type ADFMark struct {
+	Type  string                 `json:"type"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// MarkdownToADF converts github flavored markdown to an atlassian document format document
+func MarkdownToADF(md string) *ADFNode {
+	return &ADFNode{
+		Type:    "doc",
+		Version: 1,
+		Content: adfBlocks(parseMarkdown(md)),
+	}
+}
+
+func adfBlocks(blocks []mdBlock) []*ADFNode {
+	nodes := make([]*ADFNode, 0, len(blocks))
+	for _, block := range blocks {
+		nodes = append(nodes, adfBlock(block))
+	}
+	return nodes
+}
+
+func adfBlock(block mdBlock) *ADFNode {
+	switch block.kind {
+	case mdHeading:
+		return &ADFNode{
+			Type:    "heading",
+			Attrs:   map[string]interface{}{"level": block.level},
+			Content: adfInline(parseInline(block.text), nil),
+		}
+	case mdCode:
+		node := &ADFNode{Type: "codeBlock"}
+		if block.lang != "" {
+			node.Attrs = map[string]interface{}{"language": block.lang}
+		}
+		// text nodes can't be empty
+		if block.text != "" {
+			node.Content = []*ADFNode{{Type: "text", Text: block.text}}
+		}
+		return node
+	case mdTable:
+		table := &ADFNode{Type: "table"}
+		table.Content = append(table.Content, adfTableRow(block.header, "tableHeader"))
+		for _, row := range block.rows {
+			table.Content = append(table.Content, adfTableRow(row, "tableCell"))
+		}
+		return table
+	case mdList:
+		return adfList(block.list)
+	case mdQuote:
+		return &ADFNode{Type: "blockquote", Content: adfBlocks(block.children)}
+	case mdRule:
+		return &ADFNode{Type: "rule"}
+	default:
+		return adfParagraph(block.text)
+	}
+}
+
+func adfParagraph(text string) *ADFNode {
+	return &ADFNode{Type: "paragraph", Content: adfInline(parseInline(text), nil)}
+}
+
+func adfTableRow(cells []string, cellType string) *ADFNode {
+	row := &ADFNode{Type: "tableRow"}
+	for _, cell := range cells {
+		row.Content = append(row.Content, &ADFNode{
+			Type:    cellType,
+			Content: []*ADFNode{adfParagraph(cell)},
+		})
+	}
+	return row
+}
+
+func adfList(list *mdListBlock) *ADFNode {
+	node := &ADFNode{Type: "bulletList"}
+	if list.ordered {
+		node.Type = "orderedList"
+	}
+	for _, item := range list.items {
+		listItem := &ADFNode{
+			Type:    "listItem",
+			Content: []*ADFNode{adfParagraph(item.text)},
+		}
+		if item.sub != nil {
+			listItem.Content = append(listItem.Content, adfList(item.sub))
+		}
+		node.Content = append(node.Content, listItem)
+	}
+	return node
+}
+
+// adfInline flattens inline markdown into text nodes with marks
+func adfInline(nodes []mdInline, marks []*ADFMark) []*ADFNode {
+	var content []*ADFNode
+	withMark := func(mark *ADFMark) []*ADFMark {
+		return append(append([]*ADFMark{}, marks...), mark)
+	}
+	for _, node := range nodes {
+		switch node.kind {
+		case mdText:
+			if node.text != "" {
+				content = append(content, &ADFNode{Type: "text", Text: node.text, Marks: marks})
+			}
+		case mdBreak:
+			content = append(content, &ADFNode{Type: "hardBreak"})
+		case mdStrong:
+			content = append(content, adfInline(node.children, withMark(&ADFMark{Type: "strong"}))...)
+		case mdEmphasis:
+			content = append(content, adfInline(node.children, withMark(&ADFMark{Type: "em"}))...)
+		case mdStrike:
+			content = append(content, adfInline(node.children, withMark(&ADFMark{Type: "strike"}))...)
+		case mdCodeSpan:
+			// code can only be combined with links
+			var codeMarks []*ADFMark
+			for _, mark := range marks {
+				if mark.Type == "link" {
+					codeMarks = append(codeMarks, mark)
+				}
+			}
+			if node.text != "" {
+				codeMarks = append(codeMarks, &ADFMark{Type: "code"})
+				content = append(content, &ADFNode{Type: "text", Text: node.text, Marks: codeMarks})
+			}
+		case mdLink:
+			children := node.children
+			if len(children) == 0 {
+				children = []mdInline{{kind: mdText, text: node.href}}
+			}
+			content = append(content, adfInline(children, withMark(&ADFMark{
+				Type:  "link",
+				Attrs: map[string]interface{}{"href": node.href},
+			}))...)
+		case mdImage:
+			// media has to be uploaded, link to the image instead
+			text := strings.TrimSpace(node.text)
+			if text == "" {
+				text = node.href
+			}
+			content = append(content, &ADFNode{Type: "text", Text: text, Marks: withMark(&ADFMark{
+				Type:  "link",
+				Attrs: map[string]interface{}{"href": node.href},
+			})})
+		}
+	}
+	return content
+}
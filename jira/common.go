@@ -1,21 +1,42 @@
 package jira
 
 import (
+	"net/http"
+
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
 	gojira "github.com/andygrunwald/go-jira"
 )
 
 type CommonArgs struct {
 	InstanceUrl string
 	Project     string
-	User        string
-	Password    string
-	Board       int
+	// User and Password (an api token on cloud) are used for basic auth
+	User     string
+	Password string
+	// Token is a data center personal access token, sent as a bearer token instead of basic auth
+	Token string
+	Board int
+	// UserMap maps github logins to jira users, see LoadUserMap
+	UserMap map[string]string
 }
 
 func GetClient(args *CommonArgs) (*gojira.Client, error) {
+	var transport http.RoundTripper
+	if dryrun.Enabled {
+		transport = &dryrun.Transport{}
+	}
+
+	if args.Token != "" {
+		tp := gojira.BearerAuthTransport{
+			Token:     args.Token,
+			Transport: transport,
+		}
+		return gojira.NewClient(tp.Client(), args.InstanceUrl)
+	}
 	tp := gojira.BasicAuthTransport{
-		Username: args.User,
-		Password: args.Password,
+		Username:  args.User,
+		Password:  args.Password,
+		Transport: transport,
 	}
 	return gojira.NewClient(tp.Client(), args.InstanceUrl)
 }
@@ -0,0 +1,115 @@
+package jira
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
+	gojira "github.com/andygrunwald/go-jira"
+)
+
+// fingerprintLabelPrefix marks labels which identify an issue created for a dedupe key
+const fingerprintLabelPrefix = "cifp-"
+
+// occurrencePrefix starts the comments added when a deduplicated issue happens again
+const occurrencePrefix = "This issue occurred again"
+
+// FingerprintLabel returns the label stored on issues created with a dedupe key.
+// The key is hashed so it can contain anything (spaces aren't allowed in labels) and stays short.
+func FingerprintLabel(dedupeKey string) string {
+	sum := sha256.Sum256([]byte(dedupeKey))
+	return fingerprintLabelPrefix + hex.EncodeToString(sum[:])[:16]
+}
+
+// fingerprintJQL matches unresolved issues in the project with the fingerprint label, newest first
+func fingerprintJQL(project string, label string) string {
+	return fmt.Sprintf(`project = "%s" AND labels = "%s" AND statusCategory != Done ORDER BY created DESC`, project, label)
+}
+
+// FindOpenIssueByDedupeKey returns the newest unresolved issue created with the dedupe key, or nil if there is none
+func FindOpenIssueByDedupeKey(commonArgs *CommonArgs, dedupeKey string) (*gojira.Issue, error) {
+	issues, err := searchOpenIssuesByDedupeKey(commonArgs, dedupeKey, 1)
+	if err != nil || len(issues) == 0 {
+		return nil, err
+	}
+	return &issues[0], nil
+}
+
+func searchOpenIssuesByDedupeKey(commonArgs *CommonArgs, dedupeKey string, maxResults int) ([]gojira.Issue, error) {
+	client, err := GetClient(commonArgs)
+	if err != nil {
+		return nil, fmt.Errorf("get client: %w", err)
+	}
+
+	jql := fingerprintJQL(commonArgs.Project, FingerprintLabel(dedupeKey))
+	issues, _, err := client.Issue.Search(jql, &gojira.SearchOptions{
+		MaxResults: maxResults,
+		Fields:     []string{"summary", "labels", "comment"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search issues (%s): %w", jql, err)
+	}
+	return issues, nil
+}
+
+// countOccurrences returns how many times the issue happened: once when it was created plus once per occurrence comment
+func countOccurrences(issue *gojira.Issue) int {
+	count := 1
+	if issue.Fields == nil || issue.Fields.Comments == nil {
+		return count
+	}
+	for _, comment := range issue.Fields.Comments.Comments {
+		if comment != nil && strings.HasPrefix(comment.Body, occurrencePrefix) {
+			count++
+		}
+	}
+	return count
+}
+
+func occurrenceComment(occurrence int, description string) string {
+	body := fmt.Sprintf("%s (occurrence %d).", occurrencePrefix, occurrence)
+	if description != "" {
+		body += "\n\n" + description
+	}
+	return body
+}
+
+// CreateOrCommentIssue creates an issue, unless args.DedupeKey is set and an unresolved issue with the same key exists.
+// The existing issue gets a comment with the occurrence count (and the description) instead.
+// Returns the issue key and whether it was created.
+func CreateOrCommentIssue(args *CreateIssueArgs) (string, bool, error) {
+	if args.DedupeKey == "" {
+		key, err := CreateIssue(args)
+		return key, err == nil, err
+	}
+
+	existing, err := FindOpenIssueByDedupeKey(args.Common, args.DedupeKey)
+	if err != nil && dryrun.Enabled {
+		dryrun.Printf("unable to search for an existing issue, assuming there is none: %v\n", err)
+		existing, err = nil, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if existing == nil {
+		key, err := CreateIssue(args)
+		return key, err == nil, err
+	}
+
+	client, err := GetClient(args.Common)
+	if err != nil {
+		return "", false, fmt.Errorf("get client: %w", err)
+	}
+	comment := &gojira.Comment{
+		Body: occurrenceComment(countOccurrences(existing)+1, args.Description),
+	}
+	_, _, err = client.Issue.AddComment(existing.Key, comment)
+	if err != nil {
+		return "", false, fmt.Errorf("comment on %s: %w", existing.Key, err)
+	}
+	fmt.Fprintf(os.Stderr, "%s already exists for this dedupe key, added a comment\n", existing.Key)
+	return existing.Key, false, nil
+}
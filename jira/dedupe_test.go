@@ -0,0 +1,64 @@
+package jira
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprintLabel(t *testing.T) {
+	label := FingerprintLabel("nightly e2e / checkout")
+	require.Equal(t, label, FingerprintLabel("nightly e2e / checkout"))
+	require.NotEqual(t, label, FingerprintLabel("nightly e2e / payments"))
+	require.True(t, strings.HasPrefix(label, "cifp-"))
+	require.Len(t, label, len("cifp-")+16)
+	require.NotContains(t, label, " ")
+}
+
+func TestCreateOrCommentIssue(t *testing.T) {
+	fake, common := newFakeJira(t)
+	args := func() *CreateIssueArgs {
+		return &CreateIssueArgs{
+			Common:      common,
+			Summary:     "nightly failed",
+			Description: "details",
+			Type:        "Bug",
+			Labels:      []string{"nightly"},
+			DedupeKey:   "nightly",
+		}
+	}
+
+	key, created, err := CreateOrCommentIssue(args())
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, "PROJ-1", key)
+	require.Equal(t, []string{"nightly", FingerprintLabel("nightly")}, fake.issues[0].Labels)
+	require.Equal(t, `project = "PROJ" AND labels = "`+FingerprintLabel("nightly")+`" AND statusCategory != Done ORDER BY created DESC`, fake.jql[0])
+
+	for _, occurrence := range []int{2, 3} {
+		key, created, err = CreateOrCommentIssue(args())
+		require.NoError(t, err)
+		require.False(t, created)
+		require.Equal(t, "PROJ-1", key)
+		require.Len(t, fake.issues, 1)
+		require.Equal(t, fmt.Sprintf("This issue occurred again (occurrence %d).\n\ndetails", occurrence), fake.issues[0].Comments[occurrence-2])
+	}
+
+	// resolved issues aren't reused
+	fake.issues[0].Resolved = true
+	key, created, err = CreateOrCommentIssue(args())
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, "PROJ-2", key)
+
+	// without a dedupe key an issue is always created
+	noDedupe := args()
+	noDedupe.DedupeKey = ""
+	key, created, err = CreateOrCommentIssue(noDedupe)
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, "PROJ-3", key)
+	require.Equal(t, []string{"nightly"}, fake.issues[2].Labels)
+}
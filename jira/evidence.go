@@ -0,0 +1,101 @@
+package jira
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
+	gojira "github.com/andygrunwald/go-jira"
+)
+
+// AddComment adds a comment to an issue
+func AddComment(commonArgs *CommonArgs, key string, body string) error {
+	client, err := GetClient(commonArgs)
+	if err != nil {
+		return fmt.Errorf("get client: %w", err)
+	}
+
+	_, resp, err := client.Issue.AddComment(key, &gojira.Comment{Body: body})
+	if err != nil {
+		return fmt.Errorf("comment on %s: %w", key, responseError(resp, err))
+	}
+	return nil
+}
+
+// AttachFiles uploads files to an issue, named after their base name
+func AttachFiles(commonArgs *CommonArgs, key string, paths []string) error {
+	client, err := GetClient(commonArgs)
+	if err != nil {
+		return fmt.Errorf("get client: %w", err)
+	}
+
+	for _, path := range paths {
+		err = attachFile(client, key, path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func attachFile(client *gojira.Client, key string, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if dryrun.Enabled {
+		// the multipart body would be the whole file
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		dryrun.Printf("attach %s (%d bytes) to %s\n", path, info.Size(), key)
+		return nil
+	}
+
+	_, resp, err := client.Issue.PostAttachment(key, file, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("attach %s to %s: %w", path, key, responseError(resp, err))
+	}
+	return nil
+}
+
+// AddRemoteLink links an issue to a url (e.g. a pull request or a ci build).
+// The url is used as the global id so adding the same url again updates the link instead of duplicating it.
+func AddRemoteLink(commonArgs *CommonArgs, key string, url string, title string) error {
+	client, err := GetClient(commonArgs)
+	if err != nil {
+		return fmt.Errorf("get client: %w", err)
+	}
+
+	if title == "" {
+		title = url
+	}
+	_, resp, err := client.Issue.AddRemoteLink(key, &gojira.RemoteLink{
+		GlobalID: url,
+		Object: &gojira.RemoteLinkObject{
+			URL:   url,
+			Title: title,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("link %s to %s: %w", key, url, responseError(resp, err))
+	}
+	return nil
+}
+
+// responseError adds the response body (which has jira's reason) to err
+func responseError(resp *gojira.Response, err error) error {
+	if resp == nil || resp.Body == nil {
+		return err
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if len(body) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, string(body))
+}
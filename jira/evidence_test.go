@@ -0,0 +1,55 @@
+package jira
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddComment(t *testing.T) {
+	fake, common := newFakeJira(t)
+	fake.issues = append(fake.issues, &fakeJiraIssue{Key: "PROJ-1"})
+
+	err := AddComment(common, "PROJ-1", "build failed")
+	require.NoError(t, err)
+	require.Equal(t, []string{"build failed"}, fake.issues[0].Comments)
+}
+
+func TestAttachFiles(t *testing.T) {
+	fake, common := newFakeJira(t)
+	fake.issues = append(fake.issues, &fakeJiraIssue{Key: "PROJ-1"})
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "test.log")
+	require.NoError(t, os.WriteFile(logPath, []byte("--- FAIL: TestX"), 0o644))
+	reportPath := filepath.Join(dir, "report.json")
+	require.NoError(t, os.WriteFile(reportPath, []byte("{}"), 0o644))
+
+	err := AttachFiles(common, "PROJ-1", []string{logPath, reportPath})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"test.log": "--- FAIL: TestX", "report.json": "{}"}, fake.issues[0].Attachments)
+
+	err = AttachFiles(common, "PROJ-1", []string{filepath.Join(dir, "missing.log")})
+	require.Error(t, err)
+}
+
+func TestAddRemoteLink(t *testing.T) {
+	fake, common := newFakeJira(t)
+	fake.issues = append(fake.issues, &fakeJiraIssue{Key: "PROJ-1"})
+
+	err := AddRemoteLink(common, "PROJ-1", "https://github.com/owner/repo/pull/1", "PR #1")
+	require.NoError(t, err)
+	err = AddRemoteLink(common, "PROJ-1", "https://github.com/owner/repo/pull/1", "PR #1: fix")
+	require.NoError(t, err)
+	err = AddRemoteLink(common, "PROJ-1", "https://ci.example.com/build/2", "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"https://github.com/owner/repo/pull/1": "PR #1: fix",
+		"https://ci.example.com/build/2":       "https://ci.example.com/build/2",
+	}, fake.issues[0].RemoteLinks)
+
+	err = AddRemoteLink(common, "PROJ-2", "https://ci.example.com/build/2", "")
+	require.ErrorContains(t, err, "Issue does not exist")
+}
@@ -0,0 +1,249 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeJiraIssue struct {
+	Key      string
+	Labels   []string
+	Resolved bool
+	// Resolution is set by the "Close" transition
+	Resolution string
+	Comments   []string
+	// Attachments maps file names to their content
+	Attachments map[string]string
+	// RemoteLinks maps global ids to titles
+	RemoteLinks map[string]string
+	// Fields are the fields the issue was created with
+	Fields map[string]interface{}
+}
+
+// fakeJiraFields are returned by the field api
+var fakeJiraFields = []map[string]interface{}{
+	{"id": "summary", "key": "summary", "name": "Summary", "schema": map[string]string{"type": "string", "system": "summary"}},
+	{"id": "customfield_10016", "key": "customfield_10016", "name": "Story Points", "custom": true, "schema": map[string]string{"type": "number"}},
+	{"id": "customfield_10020", "key": "customfield_10020", "name": "Team", "custom": true, "schema": map[string]string{"type": "option"}},
+	{"id": "customfield_10021", "key": "customfield_10021", "name": "Platforms", "custom": true, "schema": map[string]string{"type": "array", "items": "option"}},
+	{"id": "customfield_10030", "key": "customfield_10030", "name": "Reviewer", "custom": true, "schema": map[string]string{"type": "user"}},
+	{"id": "customfield_10040", "key": "customfield_10040", "name": "Environment", "custom": true, "schema": map[string]string{"type": "string"}},
+	{"id": "customfield_10041", "key": "customfield_10041", "name": "Environment", "custom": true, "schema": map[string]string{"type": "option"}},
+}
+
+// fakeJiraTransitions are offered for every issue
+var fakeJiraTransitions = []map[string]interface{}{
+	{"id": "11", "name": "Start Progress", "to": map[string]string{"name": "In Progress"}},
+	{"id": "31", "name": "Close", "to": map[string]string{"name": "Done"}, "fields": map[string]interface{}{
+		"resolution": map[string]bool{"required": true},
+	}},
+}
+
+// fakeJira implements the issue create, search and comment endpoints with a tiny subset of jql:
+// the fingerprint label and statusCategory != Done. It also implements user search, fields, transitions, attachments and remote links.
+type fakeJira struct {
+	mu     sync.Mutex
+	issues []*fakeJiraIssue
+	jql    []string
+	users  []fakeJiraUser
+	// dataCenter rejects user searches with query, like data center does
+	dataCenter bool
+	// authorization headers of all requests
+	authorization []string
+}
+
+type fakeJiraUser struct {
+	AccountID    string `json:"accountId,omitempty"`
+	Name         string `json:"name,omitempty"`
+	EmailAddress string `json:"emailAddress,omitempty"`
+	DisplayName  string `json:"displayName"`
+	Active       bool   `json:"active"`
+}
+
+func newFakeJira(t *testing.T) (*fakeJira, *CommonArgs) {
+	fake := &fakeJira{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return fake, &CommonArgs{
+		InstanceUrl: srv.URL,
+		Project:     "PROJ",
+		User:        "user",
+		Password:    "password",
+	}
+}
+
+func (f *fakeJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.authorization = append(f.authorization, r.Header.Get("Authorization"))
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/user/search":
+		query := r.URL.Query().Get("query")
+		if f.dataCenter {
+			if query != "" {
+				http.Error(w, `{"errorMessages": ["The username query parameter was not provided"]}`, http.StatusBadRequest)
+				return
+			}
+			query = r.URL.Query().Get("username")
+		}
+		users := []fakeJiraUser{}
+		for _, user := range f.users {
+			for _, value := range []string{user.Name, user.EmailAddress, user.DisplayName} {
+				if value != "" && strings.HasPrefix(strings.ToLower(value), strings.ToLower(query)) {
+					users = append(users, user)
+					break
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(users)
+	case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/search":
+		jql := r.URL.Query().Get("jql")
+		f.jql = append(f.jql, jql)
+		issues := []map[string]interface{}{}
+		for _, issue := range f.issues {
+			if issue.Resolved {
+				continue
+			}
+			matches := false
+			for _, label := range issue.Labels {
+				if strings.Contains(jql, fmt.Sprintf("labels = %q", label)) {
+					matches = true
+				}
+			}
+			if !matches {
+				continue
+			}
+			comments := []map[string]string{}
+			for _, comment := range issue.Comments {
+				comments = append(comments, map[string]string{"body": comment})
+			}
+			issues = append(issues, map[string]interface{}{
+				"key": issue.Key,
+				"fields": map[string]interface{}{
+					"labels":  issue.Labels,
+					"comment": map[string]interface{}{"comments": comments},
+				},
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"issues": issues, "total": len(issues)})
+	case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+		req := struct {
+			Fields map[string]interface{} `json:"fields"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		issue := &fakeJiraIssue{
+			Key:    fmt.Sprintf("PROJ-%d", len(f.issues)+1),
+			Fields: req.Fields,
+		}
+		if labels, ok := req.Fields["labels"].([]interface{}); ok {
+			for _, label := range labels {
+				issue.Labels = append(issue.Labels, label.(string))
+			}
+		}
+		f.issues = append(f.issues, issue)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{"key": issue.Key})
+	case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/field":
+		_ = json.NewEncoder(w).Encode(fakeJiraFields)
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/transitions"):
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"transitions": fakeJiraTransitions})
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/transitions"):
+		key := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/"), "/transitions")
+		req := struct {
+			Transition struct {
+				ID string `json:"id"`
+			} `json:"transition"`
+			Fields struct {
+				Resolution *struct {
+					Name string `json:"name"`
+				} `json:"resolution"`
+			} `json:"fields"`
+			Update struct {
+				Comment []struct {
+					Add struct {
+						Body string `json:"body"`
+					} `json:"add"`
+				} `json:"comment"`
+			} `json:"update"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		issue := f.issue(key)
+		if issue == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if req.Transition.ID == "31" {
+			if req.Fields.Resolution == nil {
+				http.Error(w, `{"errors": {"resolution": "Resolution is required."}}`, http.StatusBadRequest)
+				return
+			}
+			issue.Resolved = true
+			issue.Resolution = req.Fields.Resolution.Name
+		}
+		for _, comment := range req.Update.Comment {
+			issue.Comments = append(issue.Comments, comment.Add.Body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/attachments"):
+		issue := f.issue(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/"), "/attachments"))
+		file, header, err := r.FormFile("file")
+		if issue == nil || err != nil || r.Header.Get("X-Atlassian-Token") == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(file)
+		if issue.Attachments == nil {
+			issue.Attachments = map[string]string{}
+		}
+		issue.Attachments[header.Filename] = string(content)
+		_ = json.NewEncoder(w).Encode([]map[string]string{{"filename": header.Filename}})
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/remotelink"):
+		issue := f.issue(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/"), "/remotelink"))
+		req := struct {
+			GlobalID string `json:"globalId"`
+			Object   struct {
+				URL   string `json:"url"`
+				Title string `json:"title"`
+			} `json:"object"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if issue == nil {
+			http.Error(w, `{"errorMessages": ["Issue does not exist"]}`, http.StatusNotFound)
+			return
+		}
+		if issue.RemoteLinks == nil {
+			issue.RemoteLinks = map[string]string{}
+		}
+		issue.RemoteLinks[req.GlobalID] = req.Object.Title
+		_ = json.NewEncoder(w).Encode(map[string]int{"id": len(issue.RemoteLinks)})
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/comment"):
+		key := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/"), "/comment")
+		req := struct {
+			Body string `json:"body"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if issue := f.issue(key); issue != nil {
+			issue.Comments = append(issue.Comments, req.Body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{"body": req.Body})
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (f *fakeJira) issue(key string) *fakeJiraIssue {
+	for _, issue := range f.issues {
+		if issue.Key == key {
+			return issue
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
+	gojira "github.com/andygrunwald/go-jira"
+)
+
+// accountIDRegex matches jira cloud account ids (557058:f58131cb-b67d-43c7-b30d-6b58d40bd077 or 5b10ac8d82e05b22cc7d4ef5)
+var accountIDRegex = regexp.MustCompile(`^([0-9a-f]{24}|\d+:[0-9a-f-]{36})$`)
+
+// resolveCustomFields maps field names (or ids) to ids and converts the values to the json the field's schema expects
+func resolveCustomFields(client *gojira.Client, userMap map[string]string, customFields map[string]string) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(customFields))
+	if len(customFields) == 0 {
+		return resolved, nil
+	}
+
+	fields, _, err := client.Field.GetList()
+	if err != nil && dryrun.Enabled {
+		dryrun.Printf("unable to list fields, custom fields are sent as is: %v\n", err)
+		for name, value := range customFields {
+			resolved[name] = value
+		}
+		return resolved, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list fields: %w", err)
+	}
+	findUser := func(query string) (*gojira.User, error) {
+		return resolveUser(client, userMap, query)
+	}
+	for name, value := range customFields {
+		field, err := findField(fields, name)
+		if err != nil {
+			return nil, err
+		}
+		coerced, err := coerceFieldValue(field, value, findUser)
+		if err != nil {
+			return nil, fmt.Errorf("field %q (%s): %w", field.Name, field.ID, err)
+		}
+		resolved[field.ID] = coerced
+	}
+	return resolved, nil
+}
+
+// findField finds a field by id (customfield_10016) or name (Story Points, case insensitive)
+func findField(fields []gojira.Field, name string) (*gojira.Field, error) {
+	for i := range fields {
+		if fields[i].ID == name || fields[i].Key == name {
+			return &fields[i], nil
+		}
+	}
+
+	var matches []*gojira.Field
+	for i := range fields {
+		if strings.EqualFold(fields[i].Name, name) {
+			matches = append(matches, &fields[i])
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no field named %q", name)
+	case 1:
+		return matches[0], nil
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, match := range matches {
+			ids = append(ids, match.ID)
+		}
+		return nil, fmt.Errorf("%d fields are named %q, use the id instead: %s", len(matches), name, strings.Join(ids, ", "))
+	}
+}
+
+// coerceFieldValue converts a flag value to the json shape of the field's schema.
+// Values which are already json objects or arrays are sent as is.
+// Arrays are comma separated and cascading selects are "parent > child".
+// Users are looked up with findUser, if it is nil account ids and usernames are sent as is.
+func coerceFieldValue(field *gojira.Field, value string, findUser func(string) (*gojira.User, error)) (interface{}, error) {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var raw interface{}
+		if json.Unmarshal([]byte(trimmed), &raw) == nil {
+			return raw, nil
+		}
+	}
+
+	if field.Schema.Type == "array" {
+		items := []interface{}{}
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			coerced, err := coerceScalar(field.Schema.Items, item, findUser)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, coerced)
+		}
+		return items, nil
+	}
+	return coerceScalar(field.Schema.Type, value, findUser)
+}
+
+func coerceScalar(schemaType string, value string, findUser func(string) (*gojira.User, error)) (interface{}, error) {
+	switch schemaType {
+	case "number":
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't a number", value)
+		}
+		return number, nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't a date (2006-01-02)", value)
+		}
+		return value, nil
+	case "datetime":
+		// jira rejects rfc3339's colon in the offset
+		datetime, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't a date time (2006-01-02T15:04:05Z07:00)", value)
+		}
+		return datetime.Format("2006-01-02T15:04:05.000-0700"), nil
+	case "option":
+		return map[string]interface{}{"value": value}, nil
+	case "option-with-child":
+		parent, child, found := strings.Cut(value, ">")
+		option := map[string]interface{}{"value": strings.TrimSpace(parent)}
+		if found {
+			option["child"] = map[string]interface{}{"value": strings.TrimSpace(child)}
+		}
+		return option, nil
+	case "user":
+		if findUser != nil {
+			user, err := findUser(value)
+			if err != nil {
+				return nil, err
+			}
+			if user.AccountID != "" {
+				return map[string]interface{}{"accountId": user.AccountID}, nil
+			}
+			return map[string]interface{}{"name": user.Name}, nil
+		}
+		if accountIDRegex.MatchString(value) {
+			return map[string]interface{}{"accountId": value}, nil
+		}
+		return map[string]interface{}{"name": value}, nil
+	case "priority", "version", "component", "group", "resolution":
+		return map[string]interface{}{"name": value}, nil
+	case "issuelink":
+		return map[string]interface{}{"key": value}, nil
+	default:
+		// string, any and custom types
+		return value, nil
+	}
+}
@@ -0,0 +1,87 @@
+package jira
+
+import (
+	"testing"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoerceFieldValue(t *testing.T) {
+	tests := []struct {
+		schema   gojira.FieldSchema
+		value    string
+		expected interface{}
+		err      string
+	}{
+		{schema: gojira.FieldSchema{Type: "string"}, value: "3", expected: "3"},
+		{schema: gojira.FieldSchema{Type: "number"}, value: " 3.5", expected: 3.5},
+		{schema: gojira.FieldSchema{Type: "number"}, value: "three", err: `"three" isn't a number`},
+		{schema: gojira.FieldSchema{Type: "option"}, value: "Payments", expected: map[string]interface{}{"value": "Payments"}},
+		{schema: gojira.FieldSchema{Type: "option-with-child"}, value: "EU > Berlin", expected: map[string]interface{}{
+			"value": "EU",
+			"child": map[string]interface{}{"value": "Berlin"},
+		}},
+		{schema: gojira.FieldSchema{Type: "array", Items: "option"}, value: "ios, android", expected: []interface{}{
+			map[string]interface{}{"value": "ios"},
+			map[string]interface{}{"value": "android"},
+		}},
+		{schema: gojira.FieldSchema{Type: "array", Items: "string"}, value: "a,b,", expected: []interface{}{"a", "b"}},
+		{schema: gojira.FieldSchema{Type: "array", Items: "number"}, value: "1,x", err: `"x" isn't a number`},
+		{schema: gojira.FieldSchema{Type: "array", Items: "version"}, value: "1.2", expected: []interface{}{map[string]interface{}{"name": "1.2"}}},
+		{schema: gojira.FieldSchema{Type: "user"}, value: "5b10ac8d82e05b22cc7d4ef5", expected: map[string]interface{}{"accountId": "5b10ac8d82e05b22cc7d4ef5"}},
+		{schema: gojira.FieldSchema{Type: "user"}, value: "jdoe", expected: map[string]interface{}{"name": "jdoe"}},
+		{schema: gojira.FieldSchema{Type: "date"}, value: "2024-02-30", err: `"2024-02-30" isn't a date (2006-01-02)`},
+		{schema: gojira.FieldSchema{Type: "date"}, value: "2024-02-03", expected: "2024-02-03"},
+		{schema: gojira.FieldSchema{Type: "datetime"}, value: "2024-02-03T10:00:00+01:00", expected: "2024-02-03T10:00:00.000+0100"},
+		{schema: gojira.FieldSchema{Type: "option"}, value: `{"id": "10001"}`, expected: map[string]interface{}{"id": "10001"}},
+		{schema: gojira.FieldSchema{Type: "string"}, value: "{not json", expected: "{not json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema.Type+"/"+tt.value, func(t *testing.T) {
+			actual, err := coerceFieldValue(&gojira.Field{Schema: tt.schema}, tt.value, nil)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestCreateIssueCustomFields(t *testing.T) {
+	fake, common := newFakeJira(t)
+	args := &CreateIssueArgs{
+		Common:  common,
+		Summary: "summary",
+		Type:    "Task",
+		CustomFields: map[string]string{
+			"story points":      "3",
+			"Team":              "Payments",
+			"Platforms":         "ios,android",
+			"customfield_10040": "staging",
+		},
+	}
+
+	key, err := CreateIssue(args)
+	require.NoError(t, err)
+	require.Equal(t, "PROJ-1", key)
+	fields := fake.issues[0].Fields
+	require.Equal(t, 3.0, fields["customfield_10016"])
+	require.Equal(t, map[string]interface{}{"value": "Payments"}, fields["customfield_10020"])
+	require.Equal(t, []interface{}{map[string]interface{}{"value": "ios"}, map[string]interface{}{"value": "android"}}, fields["customfield_10021"])
+	require.Equal(t, "staging", fields["customfield_10040"])
+
+	args.CustomFields = map[string]string{"Environment": "staging"}
+	_, err = CreateIssue(args)
+	require.EqualError(t, err, `2 fields are named "Environment", use the id instead: customfield_10040, customfield_10041`)
+
+	args.CustomFields = map[string]string{"Story Points": "lots"}
+	_, err = CreateIssue(args)
+	require.EqualError(t, err, `field "Story Points" (customfield_10016): "lots" isn't a number`)
+
+	args.CustomFields = map[string]string{"Points": "3"}
+	_, err = CreateIssue(args)
+	require.EqualError(t, err, `no field named "Points"`)
+}
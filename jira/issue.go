@@ -3,21 +3,24 @@ package jira
 import (
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
 	gojira "github.com/andygrunwald/go-jira"
 )
 
 type CreateIssueArgs struct {
-	Common       *CommonArgs
-	Summary      string
-	Description  string
-	Assignee     string
-	Type         string
-	Labels       []string
+	Common      *CommonArgs
+	Summary     string
+	Description string
+	Assignee    string
+	Type        string
+	Labels      []string
+	// CustomFields maps field names or ids to values, which are converted to the type of the field (see coerceFieldValue)
 	CustomFields map[string]string
 	Components   []string
+	// DedupeKey identifies the failure, see CreateOrCommentIssue. It is stored as a FingerprintLabel.
+	DedupeKey string
 }
 
 func CreateIssue(args *CreateIssueArgs) (string, error) {
@@ -26,9 +29,9 @@ func CreateIssue(args *CreateIssueArgs) (string, error) {
 		return "", fmt.Errorf("get client: %w", err)
 	}
 
-	customFields := make(map[string]interface{})
-	for k, v := range args.CustomFields {
-		customFields[k] = v
+	customFields, err := resolveCustomFields(client, args.Common.UserMap, args.CustomFields)
+	if err != nil {
+		return "", err
 	}
 
 	if args.Type == "Auto" {
@@ -39,6 +42,11 @@ func CreateIssue(args *CreateIssueArgs) (string, error) {
 		}
 	}
 
+	labels := args.Labels
+	if args.DedupeKey != "" {
+		labels = append(append([]string{}, labels...), FingerprintLabel(args.DedupeKey))
+	}
+
 	issue := &gojira.Issue{
 		Fields: &gojira.IssueFields{
 			Project: gojira.Project{
@@ -49,7 +57,7 @@ func CreateIssue(args *CreateIssueArgs) (string, error) {
 			},
 			Description: args.Description,
 			Summary:     args.Summary,
-			Labels:      args.Labels,
+			Labels:      labels,
 			Unknowns:    customFields,
 		},
 	}
@@ -68,12 +76,15 @@ func CreateIssue(args *CreateIssueArgs) (string, error) {
 	}
 
 	if args.Assignee != "" {
-		users, _, err := client.User.Find(args.Assignee)
+		assignee, err := resolveUser(client, args.Common.UserMap, args.Assignee)
+		if err != nil && dryrun.Enabled {
+			dryrun.Printf("unable to resolve assignee, sent as is: %v\n", err)
+			assignee, err = &gojira.User{Name: args.Assignee}, nil
+		}
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error finding user: %v\n", err)
-		} else {
-			issue.Fields.Assignee = &users[0]
+			return "", fmt.Errorf("assignee: %w", err)
 		}
+		issue.Fields.Assignee = userRef(assignee)
 	}
 
 	issue, resp, err := client.Issue.Create(issue)
@@ -81,6 +92,10 @@ func CreateIssue(args *CreateIssueArgs) (string, error) {
 		body, _ := io.ReadAll(resp.Body)
 		return "", fmt.Errorf("create issue: %w: %s", err, string(body))
 	}
+	if dryrun.Enabled {
+		// the issue wasn't created, use a placeholder key for the next steps
+		return args.Common.Project + "-DRYRUN", nil
+	}
 	return issue.Key, nil
 }
 
@@ -0,0 +1,432 @@
+package jira
+
+import (
+	"regexp"
+	"strings"
+)
+
+// a small github flavored markdown parser, enough for the reports this tool generates:
+// headings, fenced code, tables, lists, quotes, rules, paragraphs and inline emphasis, code and links.
+// html used by github comments (details, summary, comments) is dropped.
+
+type mdBlockKind int
+
+const (
+	mdParagraph mdBlockKind = iota
+	mdHeading
+	mdCode
+	mdTable
+	mdList
+	mdQuote
+	mdRule
+)
+
+type mdBlock struct {
+	kind mdBlockKind
+	// level of a heading
+	level int
+	// text of a paragraph or heading (inline markdown, lines separated by \n) or code
+	text string
+	// lang of a code block
+	lang   string
+	header []string
+	rows   [][]string
+	list   *mdListBlock
+	// children of a quote
+	children []mdBlock
+}
+
+type mdListBlock struct {
+	ordered bool
+	items   []*mdListItem
+}
+
+type mdListItem struct {
+	text string
+	sub  *mdListBlock
+}
+
+var (
+	mdFenceRegex     = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([^\\s`]*)")
+	mdHeadingRegex   = regexp.MustCompile(`^\s{0,3}(#{1,6})\s+(.*?)(\s+#+)?\s*$`)
+	mdRuleRegex      = regexp.MustCompile(`^\s{0,3}([-*_])(\s*([-*_])){2,}\s*$`)
+	mdListItemRegex  = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	mdTableSepRegex  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdQuoteRegex     = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	mdSummaryRegex   = regexp.MustCompile(`<summary>(.*?)</summary>`)
+	mdHTMLTagRegex   = regexp.MustCompile(`</?(details|summary|div|p|br|span)(\s[^>]*)?/?>`)
+	mdHTMLCommentRgx = regexp.MustCompile(`<!--.*?-->`)
+)
+
+// parseMarkdown splits markdown into blocks
+func parseMarkdown(md string) []mdBlock {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	return parseMarkdownLines(lines)
+}
+
+func parseMarkdownLines(lines []string) []mdBlock {
+	var blocks []mdBlock
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, mdBlock{kind: mdParagraph, text: strings.Join(paragraph, "\n")})
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		if match := mdFenceRegex.FindStringSubmatch(lines[i]); match != nil {
+			flush()
+			fence := match[1]
+			var code []string
+			for i++; i < len(lines); i++ {
+				trimmed := strings.TrimSpace(lines[i])
+				if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, mdBlock{kind: mdCode, lang: match[2], text: strings.Join(code, "\n")})
+			continue
+		}
+
+		line := stripHTML(lines[i])
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case mdHeadingRegex.MatchString(line):
+			flush()
+			match := mdHeadingRegex.FindStringSubmatch(line)
+			blocks = append(blocks, mdBlock{kind: mdHeading, level: len(match[1]), text: match[2]})
+		case mdRuleRegex.MatchString(line) && sameRuleChars(line):
+			flush()
+			blocks = append(blocks, mdBlock{kind: mdRule})
+		case mdQuoteRegex.MatchString(line):
+			flush()
+			var quoted []string
+			for ; i < len(lines); i++ {
+				match := mdQuoteRegex.FindStringSubmatch(stripHTML(lines[i]))
+				if match == nil {
+					break
+				}
+				quoted = append(quoted, match[1])
+			}
+			i--
+			blocks = append(blocks, mdBlock{kind: mdQuote, children: parseMarkdownLines(quoted)})
+		case isTableStart(lines, i):
+			flush()
+			block := mdBlock{kind: mdTable, header: splitTableRow(line)}
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				block.rows = append(block.rows, splitTableRow(stripHTML(lines[i])))
+			}
+			i--
+			blocks = append(blocks, block)
+		case mdListItemRegex.MatchString(line):
+			flush()
+			var list *mdListBlock
+			list, i = parseList(lines, i)
+			i--
+			blocks = append(blocks, mdBlock{kind: mdList, list: list})
+		default:
+			paragraph = append(paragraph, strings.TrimSpace(line))
+		}
+	}
+	flush()
+	return blocks
+}
+
+// stripHTML removes the html github comments use for layout, summaries become bold
+func stripHTML(line string) string {
+	line = mdHTMLCommentRgx.ReplaceAllString(line, "")
+	line = mdSummaryRegex.ReplaceAllStringFunc(line, func(summary string) string {
+		summary = mdSummaryRegex.FindStringSubmatch(summary)[1]
+		if strings.Contains(summary, "**") {
+			return summary
+		}
+		return "**" + summary + "**"
+	})
+	return mdHTMLTagRegex.ReplaceAllString(line, "")
+}
+
+// sameRuleChars is true if a rule uses a single character ("- * -" isn't a rule)
+func sameRuleChars(line string) bool {
+	chars := strings.Trim(strings.TrimSpace(line), " \t")
+	chars = strings.ReplaceAll(strings.ReplaceAll(chars, " ", ""), "\t", "")
+	return strings.Count(chars, chars[:1]) == len(chars)
+}
+
+// mdBlockStart is true for lines which end a list or table
+func mdBlockStart(line string) bool {
+	return mdFenceRegex.MatchString(line) || mdHeadingRegex.MatchString(line) || mdQuoteRegex.MatchString(line) ||
+		(mdRuleRegex.MatchString(line) && sameRuleChars(line))
+}
+
+// isTableStart is true if lines[i] is a table header, followed by the delimiter row (| --- | :-: |)
+func isTableStart(lines []string, i int) bool {
+	return strings.Contains(lines[i], "|") && i+1 < len(lines) &&
+		strings.Contains(lines[i+1], "|") && strings.Contains(lines[i+1], "-") && mdTableSepRegex.MatchString(lines[i+1])
+}
+
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = strings.TrimSuffix(line, "|")
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+type mdListLine struct {
+	indent  int
+	ordered bool
+	text    string
+}
+
+// parseList reads list items starting at lines[start], returning the list and the index of the first line after it
+func parseList(lines []string, start int) (*mdListBlock, int) {
+	var items []mdListLine
+	i := start
+	for ; i < len(lines); i++ {
+		line := stripHTML(lines[i])
+		if match := mdListItemRegex.FindStringSubmatch(line); match != nil && !(mdRuleRegex.MatchString(line) && sameRuleChars(line)) {
+			item := mdListLine{
+				indent:  len(strings.ReplaceAll(match[1], "\t", "    ")),
+				ordered: !strings.ContainsAny(match[2], "-*+"),
+				text:    match[3],
+			}
+			// switching between bullets and numbers at the top level starts another list
+			if len(items) > 0 && item.indent <= items[0].indent && item.ordered != items[0].ordered {
+				break
+			}
+			items = append(items, item)
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			// a blank line only continues the list if another item follows
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next < len(lines) && mdListItemRegex.MatchString(lines[next]) {
+				i = next - 1
+				continue
+			}
+			break
+		}
+		if mdBlockStart(line) || isTableStart(lines, i) {
+			break
+		}
+		// continuation of the previous item
+		items[len(items)-1].text += "\n" + strings.TrimSpace(line)
+	}
+	return buildList(items), i
+}
+
+func buildList(items []mdListLine) *mdListBlock {
+	type level struct {
+		indent int
+		list   *mdListBlock
+	}
+	root := &mdListBlock{ordered: items[0].ordered}
+	stack := []level{{indent: items[0].indent, list: root}}
+	for _, item := range items {
+		for len(stack) > 1 && item.indent < stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		top := stack[len(stack)-1]
+		if item.indent > top.indent && len(top.list.items) > 0 {
+			parent := top.list.items[len(top.list.items)-1]
+			if parent.sub == nil {
+				parent.sub = &mdListBlock{ordered: item.ordered}
+			}
+			stack = append(stack, level{indent: item.indent, list: parent.sub})
+			top = stack[len(stack)-1]
+		}
+		top.list.items = append(top.list.items, &mdListItem{text: item.text})
+	}
+	return root
+}
+
+type mdInlineKind int
+
+const (
+	mdText mdInlineKind = iota
+	mdStrong
+	mdEmphasis
+	mdStrike
+	mdCodeSpan
+	mdLink
+	mdImage
+	mdBreak
+)
+
+type mdInline struct {
+	kind mdInlineKind
+	// text of text, code spans and images (alt text)
+	text string
+	// href of links and images
+	href     string
+	children []mdInline
+}
+
+// parseInline parses emphasis, code spans, links and line breaks
+func parseInline(s string) []mdInline {
+	var nodes []mdInline
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, mdInline{kind: mdText, text: text.String()})
+			text.Reset()
+		}
+	}
+	add := func(node mdInline) {
+		flush()
+		nodes = append(nodes, node)
+	}
+
+	for i := 0; i < len(s); i++ {
+		rest := s[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_{}[]()#+-.!|~<>", rune(rest[1])):
+			text.WriteByte(rest[1])
+			i++
+		case rest[0] == '\n':
+			add(mdInline{kind: mdBreak})
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			end := strings.Index(rest[ticks:], rest[:ticks])
+			if end < 0 {
+				text.WriteString(rest[:ticks])
+				i += ticks - 1
+				continue
+			}
+			add(mdInline{kind: mdCodeSpan, text: strings.TrimSpace(rest[ticks : ticks+end])})
+			i += 2*ticks + end - 1
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if node, n, ok := parseDelimited(rest, rest[:2], mdStrong, i == 0 || !isWordByte(s[i-1])); ok {
+				add(node)
+				i += n - 1
+				continue
+			}
+			text.WriteString(rest[:2])
+			i++
+		case strings.HasPrefix(rest, "~~"):
+			if node, n, ok := parseDelimited(rest, "~~", mdStrike, true); ok {
+				add(node)
+				i += n - 1
+				continue
+			}
+			text.WriteString("~~")
+			i++
+		case rest[0] == '*' || rest[0] == '_':
+			// intraword underscores (snake_case) aren't emphasis
+			if node, n, ok := parseDelimited(rest, rest[:1], mdEmphasis, rest[0] == '*' || i == 0 || !isWordByte(s[i-1])); ok {
+				add(node)
+				i += n - 1
+				continue
+			}
+			text.WriteByte(rest[0])
+		case rest[0] == '[' || strings.HasPrefix(rest, "!["):
+			image := rest[0] == '!'
+			if image {
+				rest = rest[1:]
+			}
+			label, href, n, ok := parseLink(rest)
+			if !ok {
+				text.WriteByte(s[i])
+				continue
+			}
+			if image {
+				add(mdInline{kind: mdImage, text: label, href: href})
+				i += n
+				continue
+			}
+			add(mdInline{kind: mdLink, href: href, children: parseInline(label)})
+			i += n - 1
+		case rest[0] == '<' && (strings.HasPrefix(rest, "<http://") || strings.HasPrefix(rest, "<https://")) && strings.Contains(rest, ">"):
+			href := rest[1:strings.Index(rest, ">")]
+			add(mdInline{kind: mdLink, href: href, children: []mdInline{{kind: mdText, text: href}}})
+			i += len(href) + 1
+		default:
+			text.WriteByte(rest[0])
+		}
+	}
+	flush()
+	return nodes
+}
+
+// parseDelimited parses delim...delim at the start of s, returning the node and the length consumed
+func parseDelimited(s string, delim string, kind mdInlineKind, canOpen bool) (mdInline, int, bool) {
+	if !canOpen || len(s) <= len(delim) || s[len(delim)] == ' ' {
+		return mdInline{}, 0, false
+	}
+	for start := len(delim); ; {
+		end := strings.Index(s[start:], delim)
+		if end < 0 {
+			return mdInline{}, 0, false
+		}
+		end += start
+		afterEnd := end + len(delim)
+		closes := s[end-1] != ' ' && (delim[0] != '_' || afterEnd >= len(s) || !isWordByte(s[afterEnd]))
+		// "**" inside "*...*" belongs to nested strong emphasis
+		nested := len(delim) == 1 && afterEnd < len(s) && s[afterEnd] == delim[0]
+		if closes && !nested && end > len(delim) {
+			return mdInline{kind: kind, children: parseInline(s[len(delim):end])}, afterEnd, true
+		}
+		start = end + len(delim)
+		if nested {
+			start++
+		}
+	}
+}
+
+// parseLink parses [label](href) at the start of s
+func parseLink(s string) (string, string, int, bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if i+1 >= len(s) || s[i+1] != '(' {
+				return "", "", 0, false
+			}
+			end := strings.IndexByte(s[i+2:], ')')
+			if end < 0 {
+				return "", "", 0, false
+			}
+			href := strings.TrimSpace(s[i+2 : i+2+end])
+			// drop a title: [label](href "title")
+			if space := strings.IndexAny(href, " \t"); space >= 0 {
+				href = href[:space]
+			}
+			return s[1:i], strings.Trim(href, "<>"), i + 3 + end, true
+		}
+	}
+	return "", "", 0, false
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || b >= 0x80
+}
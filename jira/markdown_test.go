@@ -0,0 +1,124 @@
+package jira
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownToWiki(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		wiki     string
+	}{
+		{
+			name:     "headings",
+			markdown: "# Title\n### Sub section ###",
+			wiki:     "h1. Title\n\nh3. Sub section",
+		},
+		{
+			name:     "inline",
+			markdown: "some *em* and **bold _both_** ~~gone~~ in snake_case with `code {x}`",
+			wiki:     "some _em_ and *bold _both_* -gone- in snake\\_case with {{code \\{x\\}}}",
+		},
+		{
+			name:     "links",
+			markdown: "[the PR](https://github.com/owner/repo/pull/1 \"title\") <https://example.com> [https://x.y](https://x.y) ![graph](https://img.png)",
+			wiki:     "[the PR|https://github.com/owner/repo/pull/1] [https://example.com] [https://x.y] !https://img.png!",
+		},
+		{
+			name:     "line breaks are kept",
+			markdown: "first line\nsecond line\n\nnext paragraph",
+			wiki:     "first line\nsecond line\n\nnext paragraph",
+		},
+		{
+			name:     "code blocks",
+			markdown: "```go\nfunc main() {\n\t*x = [1]\n}\n```\n~~~\nplain\n~~~",
+			wiki:     "{code:go}\nfunc main() {\n\t*x = [1]\n}\n{code}\n\n{noformat}\nplain\n{noformat}",
+		},
+		{
+			name:     "tables",
+			markdown: "| Resource | Op |\n|---|:-:|\n| `aws:s3` | **delete** |\n| a \\| b | |",
+			wiki:     "||Resource||Op||\n|{{aws:s3}}|*delete*|\n|a \\| b| |",
+		},
+		{
+			name:     "lists",
+			markdown: "- one\n  - nested\n    1. deep\n- two\n  continued\n\n1. first\n\n2. second",
+			wiki:     "* one\n** nested\n**# deep\n* two continued\n\n# first\n# second",
+		},
+		{
+			name:     "quotes and rules",
+			markdown: "> quoted **text**\n> more\n\n---\n\n* * *",
+			wiki:     "{quote}\nquoted *text*\nmore\n{quote}\n\n----\n\n----",
+		},
+		{
+			name:     "quotes after html",
+			markdown: "<br>> quoted\n<!-- key k -->> more",
+			wiki:     "{quote}\nquoted\nmore\n{quote}",
+		},
+		{
+			name:     "github html",
+			markdown: "<!-- key pulumi -->\n<details id=\"pulumi\"><summary>Preview: 3 changes</summary>\n\nbody\n\n</details>",
+			wiki:     "*Preview: 3 changes*\n\nbody",
+		},
+		{
+			name:     "unclosed markers are text",
+			markdown: "2 * 3 = 6, a_b and ** alone `tick",
+			wiki:     "2 \\* 3 = 6, a\\_b and \\*\\* alone `tick",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.wiki, MarkdownToWiki(tt.markdown))
+		})
+	}
+}
+
+func TestMarkdownToADF(t *testing.T) {
+	doc := MarkdownToADF("## Plan\n**bold** [`code`](https://x.y)\nnext\n\n| a |\n|---|\n| 1 |\n\n- one\n  1. nested\n\n```go\nx\n```")
+	expected := `{
+		"type": "doc",
+		"version": 1,
+		"content": [
+			{"type": "heading", "attrs": {"level": 2}, "content": [{"type": "text", "text": "Plan"}]},
+			{"type": "paragraph", "content": [
+				{"type": "text", "text": "bold", "marks": [{"type": "strong"}]},
+				{"type": "text", "text": " "},
+				{"type": "text", "text": "code", "marks": [{"type": "link", "attrs": {"href": "https://x.y"}}, {"type": "code"}]},
+				{"type": "hardBreak"},
+				{"type": "text", "text": "next"}
+			]},
+			{"type": "table", "content": [
+				{"type": "tableRow", "content": [{"type": "tableHeader", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "a"}]}]}]},
+				{"type": "tableRow", "content": [{"type": "tableCell", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "1"}]}]}]}
+			]},
+			{"type": "bulletList", "content": [
+				{"type": "listItem", "content": [
+					{"type": "paragraph", "content": [{"type": "text", "text": "one"}]},
+					{"type": "orderedList", "content": [
+						{"type": "listItem", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "nested"}]}]}
+					]}
+				]}
+			]},
+			{"type": "codeBlock", "attrs": {"language": "go"}, "content": [{"type": "text", "text": "x"}]}
+		]
+	}`
+	actual, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.JSONEq(t, expected, string(actual))
+}
+
+func TestToWiki(t *testing.T) {
+	text, err := ToWiki("**x**", FormatWiki)
+	require.NoError(t, err)
+	require.Equal(t, "**x**", text)
+
+	text, err = ToWiki("**x**", FormatMarkdown)
+	require.NoError(t, err)
+	require.Equal(t, "*x*", text)
+
+	_, err = ToWiki("x", "html")
+	require.Error(t, err)
+}
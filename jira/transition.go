@@ -0,0 +1,121 @@
+package jira
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+)
+
+// defaultResolution is set when a transition requires a resolution and none was given
+const defaultResolution = "Done"
+
+type TransitionArgs struct {
+	Common *CommonArgs
+	Key    string
+	// To is the name of the transition or of the status it leads to (case insensitive)
+	To string
+	// Resolution is set if the transition has a resolution field (defaults to Done when it is required)
+	Resolution string
+	// Comment is added with the transition (optional)
+	Comment string
+}
+
+// findTransition matches to against the transition names first, then the names of the statuses they lead to
+func findTransition(transitions []gojira.Transition, to string) (*gojira.Transition, error) {
+	for i := range transitions {
+		if strings.EqualFold(transitions[i].Name, to) {
+			return &transitions[i], nil
+		}
+	}
+	for i := range transitions {
+		if strings.EqualFold(transitions[i].To.Name, to) {
+			return &transitions[i], nil
+		}
+	}
+
+	available := make([]string, 0, len(transitions))
+	for _, transition := range transitions {
+		available = append(available, fmt.Sprintf("%q (to %q)", transition.Name, transition.To.Name))
+	}
+	return nil, fmt.Errorf("no transition %q, available transitions: %s", to, strings.Join(available, ", "))
+}
+
+// transitionPayload sets the resolution if the transition has the field
+func transitionPayload(transition *gojira.Transition, resolution string, comment string) *gojira.CreateTransitionPayload {
+	payload := &gojira.CreateTransitionPayload{
+		Transition: gojira.TransitionPayload{ID: transition.ID},
+	}
+	if field, ok := transition.Fields["resolution"]; ok {
+		if resolution == "" && field.Required {
+			resolution = defaultResolution
+		}
+		if resolution != "" {
+			payload.Fields.Resolution = &gojira.Resolution{Name: resolution}
+		}
+	}
+	if comment != "" {
+		payload.Update.Comment = []gojira.TransitionPayloadComment{
+			{Add: gojira.TransitionPayloadCommentBody{Body: comment}},
+		}
+	}
+	return payload
+}
+
+// TransitionIssue moves an issue through the workflow by transition (or status) name.
+func TransitionIssue(args *TransitionArgs) error {
+	client, err := GetClient(args.Common)
+	if err != nil {
+		return fmt.Errorf("get client: %w", err)
+	}
+
+	transitions, _, err := client.Issue.GetTransitions(args.Key)
+	if err != nil {
+		return fmt.Errorf("get transitions of %s: %w", args.Key, err)
+	}
+	transition, err := findTransition(transitions, args.To)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args.Key, err)
+	}
+
+	resp, err := client.Issue.DoTransitionWithPayload(args.Key, transitionPayload(transition, args.Resolution, args.Comment))
+	if err != nil {
+		return fmt.Errorf("transition %s to %q: %w", args.Key, transition.Name, responseError(resp, err))
+	}
+	return nil
+}
+
+type ResolveArgs struct {
+	Common     *CommonArgs
+	DedupeKey  string
+	To         string
+	Resolution string
+	Comment    string
+}
+
+// ResolveIssuesByDedupeKey transitions every unresolved issue created with the dedupe key (see CreateOrCommentIssue),
+// for when the failure which created them went away. Returns the keys of the transitioned issues.
+func ResolveIssuesByDedupeKey(args *ResolveArgs) ([]string, error) {
+	issues, err := searchOpenIssuesByDedupeKey(args.Common, args.DedupeKey, 50)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, issue := range issues {
+		err = TransitionIssue(&TransitionArgs{
+			Common:     args.Common,
+			Key:        issue.Key,
+			To:         args.To,
+			Resolution: args.Resolution,
+			Comment:    args.Comment,
+		})
+		if err != nil {
+			return keys, err
+		}
+		fmt.Fprintf(os.Stderr, "%s transitioned to %s\n", issue.Key, args.To)
+		keys = append(keys, issue.Key)
+	}
+	return keys, nil
+}
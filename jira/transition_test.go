@@ -0,0 +1,46 @@
+package jira
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitionIssue(t *testing.T) {
+	fake, common := newFakeJira(t)
+	fake.issues = append(fake.issues, &fakeJiraIssue{Key: "PROJ-1"})
+
+	// by status name, the required resolution defaults to Done
+	err := TransitionIssue(&TransitionArgs{Common: common, Key: "PROJ-1", To: "done", Comment: "fixed"})
+	require.NoError(t, err)
+	require.True(t, fake.issues[0].Resolved)
+	require.Equal(t, "Done", fake.issues[0].Resolution)
+	require.Equal(t, []string{"fixed"}, fake.issues[0].Comments)
+
+	err = TransitionIssue(&TransitionArgs{Common: common, Key: "PROJ-1", To: "Close", Resolution: "Won't Do"})
+	require.NoError(t, err)
+	require.Equal(t, "Won't Do", fake.issues[0].Resolution)
+
+	err = TransitionIssue(&TransitionArgs{Common: common, Key: "PROJ-1", To: "Reopen"})
+	require.EqualError(t, err, `PROJ-1: no transition "Reopen", available transitions: "Start Progress" (to "In Progress"), "Close" (to "Done")`)
+}
+
+func TestResolveIssuesByDedupeKey(t *testing.T) {
+	fake, common := newFakeJira(t)
+	fake.issues = append(fake.issues,
+		&fakeJiraIssue{Key: "PROJ-1", Labels: []string{FingerprintLabel("nightly")}, Resolved: true},
+		&fakeJiraIssue{Key: "PROJ-2", Labels: []string{FingerprintLabel("nightly")}},
+		&fakeJiraIssue{Key: "PROJ-3", Labels: []string{FingerprintLabel("other")}},
+	)
+
+	keys, err := ResolveIssuesByDedupeKey(&ResolveArgs{Common: common, DedupeKey: "nightly", To: "Done", Comment: "passing again"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"PROJ-2"}, keys)
+	require.True(t, fake.issues[1].Resolved)
+	require.Equal(t, []string{"passing again"}, fake.issues[1].Comments)
+	require.False(t, fake.issues[2].Resolved)
+
+	keys, err = ResolveIssuesByDedupeKey(&ResolveArgs{Common: common, DedupeKey: "nightly", To: "Done"})
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
@@ -0,0 +1,122 @@
+package jira
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadUserMap reads a yaml or json file mapping github logins to jira users (email, account id, username or display name)
+//
+//	octocat: octo.cat@example.com
+//	hubot: 5b10ac8d82e05b22cc7d4ef5
+func LoadUserMap(path string) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	userMap := map[string]string{}
+	err = yaml.Unmarshal(raw, &userMap)
+	if err != nil {
+		return nil, fmt.Errorf("parse user map %s: %w", path, err)
+	}
+	return userMap, nil
+}
+
+// resolveUser finds the jira user for an email, account id, username, display name or github login (through userMap).
+// It fails rather than guessing when several users match.
+func resolveUser(client *gojira.Client, userMap map[string]string, query string) (*gojira.User, error) {
+	query = strings.TrimSpace(strings.TrimPrefix(query, "@"))
+	if mapped, ok := lookupUserMap(userMap, query); ok {
+		query = mapped
+	}
+	if query == "" {
+		return nil, fmt.Errorf("empty jira user")
+	}
+	// account ids are what cloud wants, there is nothing to look up
+	if accountIDRegex.MatchString(query) {
+		return &gojira.User{AccountID: query}, nil
+	}
+
+	escaped := url.QueryEscape(query)
+	users, _, err := client.User.Find(escaped)
+	if err != nil {
+		// data center only searches by username
+		var usernameErr error
+		users, _, usernameErr = client.User.Find("", gojira.WithUsername(escaped))
+		if usernameErr != nil {
+			return nil, fmt.Errorf("find jira user %q: %w", query, err)
+		}
+	}
+
+	var active []gojira.User
+	for _, user := range users {
+		if user.Active {
+			active = append(active, user)
+		}
+	}
+	matches := exactUserMatches(active, query)
+	if len(matches) == 0 {
+		// the search also matches prefixes of names and emails, only use it if it is unambiguous
+		matches = active
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no active jira user matches %q", query)
+	case 1:
+		return &matches[0], nil
+	default:
+		names := make([]string, 0, len(matches))
+		for _, user := range matches {
+			names = append(names, describeUser(user))
+		}
+		return nil, fmt.Errorf("%d jira users match %q, use an email or account id: %s", len(matches), query, strings.Join(names, ", "))
+	}
+}
+
+// lookupUserMap finds a github login in the user map, github logins are case insensitive
+func lookupUserMap(userMap map[string]string, login string) (string, bool) {
+	if mapped, ok := userMap[login]; ok {
+		return mapped, true
+	}
+	for mapLogin, mapped := range userMap {
+		if strings.EqualFold(mapLogin, login) {
+			return mapped, true
+		}
+	}
+	return "", false
+}
+
+func exactUserMatches(users []gojira.User, query string) []gojira.User {
+	var matches []gojira.User
+	for _, user := range users {
+		if user.AccountID == query || user.Key == query || strings.EqualFold(user.Name, query) ||
+			strings.EqualFold(user.EmailAddress, query) || strings.EqualFold(user.DisplayName, query) {
+			matches = append(matches, user)
+		}
+	}
+	return matches
+}
+
+func describeUser(user gojira.User) string {
+	id := user.AccountID
+	if id == "" {
+		id = user.Name
+	}
+	if user.EmailAddress != "" {
+		return fmt.Sprintf("%s <%s> (%s)", user.DisplayName, user.EmailAddress, id)
+	}
+	return fmt.Sprintf("%s (%s)", user.DisplayName, id)
+}
+
+// userRef is how users are set in fields: the account id on cloud, the username on server and data center
+func userRef(user *gojira.User) *gojira.User {
+	if user.AccountID != "" {
+		return &gojira.User{AccountID: user.AccountID}
+	}
+	return &gojira.User{Name: user.Name}
+}
@@ -0,0 +1,143 @@
+package jira
+
+import (
+	"fmt"
+	"strings"
+)
+
+// description and comment formats
+const (
+	// FormatWiki is jira wiki markup, sent as is
+	FormatWiki = "wiki"
+	// FormatMarkdown is github flavored markdown, converted to wiki markup
+	FormatMarkdown = "markdown"
+)
+
+// ToWiki converts text in format (wiki or markdown) to jira wiki markup, which the v2 api (server, data center and cloud) expects
+func ToWiki(text string, format string) (string, error) {
+	switch format {
+	case FormatWiki, "":
+		return text, nil
+	case FormatMarkdown:
+		return MarkdownToWiki(text), nil
+	default:
+		return "", fmt.Errorf("invalid format %q (wiki, markdown)", format)
+	}
+}
+
+// wikiEscaper escapes characters which are markup in jira wiki text
+var wikiEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`*`, `\*`,
+	`_`, `\_`,
+	`|`, `\|`,
+	`~`, `\~`,
+	`^`, `\^`,
+)
+
+// MarkdownToWiki converts github flavored markdown to jira wiki markup
+func MarkdownToWiki(md string) string {
+	return strings.TrimSpace(wikiBlocks(parseMarkdown(md)))
+}
+
+func wikiBlocks(blocks []mdBlock) string {
+	parts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		parts = append(parts, wikiBlock(block))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func wikiBlock(block mdBlock) string {
+	switch block.kind {
+	case mdHeading:
+		return fmt.Sprintf("h%d. %s", block.level, wikiInline(parseInline(block.text)))
+	case mdCode:
+		if block.lang == "" {
+			return "{noformat}\n" + block.text + "\n{noformat}"
+		}
+		return fmt.Sprintf("{code:%s}\n%s\n{code}", block.lang, block.text)
+	case mdTable:
+		var b strings.Builder
+		b.WriteString("||")
+		for _, cell := range block.header {
+			b.WriteString(wikiCell(cell) + "||")
+		}
+		for _, row := range block.rows {
+			b.WriteString("\n|")
+			for _, cell := range row {
+				b.WriteString(wikiCell(cell) + "|")
+			}
+		}
+		return b.String()
+	case mdList:
+		var lines []string
+		wikiList(block.list, "", &lines)
+		return strings.Join(lines, "\n")
+	case mdQuote:
+		return "{quote}\n" + wikiBlocks(block.children) + "\n{quote}"
+	case mdRule:
+		return "----"
+	default:
+		return wikiInline(parseInline(block.text))
+	}
+}
+
+// wikiCell converts a table cell, empty cells need a space or jira merges them
+func wikiCell(cell string) string {
+	text := wikiInline(parseInline(cell))
+	if text == "" {
+		return " "
+	}
+	return text
+}
+
+func wikiList(list *mdListBlock, prefix string, lines *[]string) {
+	if list.ordered {
+		prefix += "#"
+	} else {
+		prefix += "*"
+	}
+	for _, item := range list.items {
+		// continuation lines would end the item
+		text := strings.ReplaceAll(wikiInline(parseInline(item.text)), "\n", " ")
+		*lines = append(*lines, prefix+" "+text)
+		if item.sub != nil {
+			wikiList(item.sub, prefix, lines)
+		}
+	}
+}
+
+func wikiInline(nodes []mdInline) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		switch node.kind {
+		case mdText:
+			b.WriteString(wikiEscaper.Replace(node.text))
+		case mdBreak:
+			b.WriteString("\n")
+		case mdStrong:
+			b.WriteString("*" + wikiInline(node.children) + "*")
+		case mdEmphasis:
+			b.WriteString("_" + wikiInline(node.children) + "_")
+		case mdStrike:
+			b.WriteString("-" + wikiInline(node.children) + "-")
+		case mdCodeSpan:
+			b.WriteString("{{" + wikiEscaper.Replace(node.text) + "}}")
+		case mdLink:
+			text := wikiInline(node.children)
+			if text == "" || text == wikiEscaper.Replace(node.href) {
+				b.WriteString("[" + node.href + "]")
+			} else {
+				b.WriteString("[" + text + "|" + node.href + "]")
+			}
+		case mdImage:
+			b.WriteString("!" + node.href + "!")
+		}
+	}
+	return b.String()
+}
@@ -97,6 +97,16 @@ func (m *Manager) Error() string {
 	return ""
 }
 
+// Diagnostics returns the errors, warnings and messages reported by pulumi
+func (m *Manager) Diagnostics() []PulumiJSONDiagnostics {
+	return m.output.Diagnostics
+}
+
+// ResourceName returns the urn without the stack prefix (aws:s3/bucket:Bucket::my-bucket)
+func (m *Manager) ResourceName(urn string) string {
+	return m.stripURN(urn)
+}
+
 func (m *Manager) stripURN(urn string) string {
 	res := strings.TrimPrefix(urn, "urn:")
 	res = strings.TrimPrefix(res, m.urnPrefix)
@@ -122,14 +132,50 @@ func (m *Manager) urnParent(urn string) string {
 
 // TreeString returns a tree that looks like the `pulumi preview` console output
 func (m *Manager) TreeString() string {
-	urnToNode := make(map[string]Tree)
+	return m.treeString(m.changedSteps())
+}
 
-	tree := NewTree(m.urnPrefix)
-	urnToNode[""] = tree
+// TreeStringLimit is TreeString cut to at most max bytes. Resources which don't fit are left out and counted in a note at the end.
+func (m *Manager) TreeStringLimit(max int) string {
+	steps := m.changedSteps()
+	res := m.treeString(steps)
+	if len(res) <= max {
+		return res
+	}
+
+	withNote := func(count int) string {
+		return m.treeString(steps[:count]) + fmt.Sprintf("… %d more resources\n", len(steps)-count)
+	}
+	// find the most resources that fit
+	low, high := 0, len(steps)-1
+	for low < high {
+		mid := (low + high + 1) / 2
+		if len(withNote(mid)) <= max {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return withNote(low)
+}
+
+func (m *Manager) changedSteps() []PulumiJSONSteps {
+	var steps []PulumiJSONSteps
 	for _, step := range m.output.Steps {
 		if step.Op == "same" || step.Op == "read" {
 			continue
 		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+func (m *Manager) treeString(steps []PulumiJSONSteps) string {
+	urnToNode := make(map[string]Tree)
+
+	tree := NewTree(m.urnPrefix)
+	urnToNode[""] = tree
+	for _, step := range steps {
 		strippedURN := m.stripURN(step.Urn)
 		urnParts := m.urnParts(strippedURN)
 		for i, urnPart := range urnParts {
@@ -165,3 +211,15 @@ func (m *Manager) TreeString() string {
 
 	return res
 }
+
+// HasChanges is true if any resource is created, updated, replaced or deleted
+func (m *Manager) HasChanges() bool {
+	c := m.output.ChangeSummary
+	return c.Create != 0 || c.Update != 0 || c.Replace != 0 || c.Delete != 0
+}
+
+// IsDestructive is true if any resource is deleted or replaced
+func (m *Manager) IsDestructive() bool {
+	c := m.output.ChangeSummary
+	return c.Replace != 0 || c.Delete != 0
+}
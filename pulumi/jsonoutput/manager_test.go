@@ -28,3 +28,16 @@ func TestNewManagerFromFile2(t *testing.T) {
 
 	t.Log(previewManager.TreeString())
 }
+
+func TestTreeStringLimit(t *testing.T) {
+	previewManager, err := NewManagerFromFile("testdata/preview-changes.json")
+	require.NoError(t, err)
+
+	tree := previewManager.TreeString()
+	require.Equal(t, tree, previewManager.TreeStringLimit(len(tree)))
+
+	limited := previewManager.TreeStringLimit(len(tree) / 2)
+	require.LessOrEqual(t, len(limited), len(tree)/2)
+	require.Contains(t, limited, "more resources")
+	t.Log(limited)
+}
@@ -0,0 +1,214 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FuncMap returns the helpers available to templates. Names and argument order follow sprig so pipelines read the same:
+// {{ .Data.name | default "none" | upper }}
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		// strings
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      title,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix string, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix string, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old string, new string, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr string, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix string, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix string, s string) bool { return strings.HasSuffix(s, suffix) },
+		"splitList":  func(sep string, s string) []string { return strings.Split(s, sep) },
+		"join":       join,
+		"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+		"trunc":      trunc,
+		"indent":     indent,
+		"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+		"quote":      func(v interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(v)) },
+		"squote":     func(v interface{}) string { return "'" + fmt.Sprint(v) + "'" },
+		"toString":   func(v interface{}) string { return fmt.Sprint(v) },
+
+		// defaults and conditions
+		"default":  defaultValue,
+		"empty":    empty,
+		"coalesce": coalesce,
+		"ternary": func(ifTrue interface{}, ifFalse interface{}, condition bool) interface{} {
+			if condition {
+				return ifTrue
+			}
+			return ifFalse
+		},
+		"fail": func(message string) (string, error) { return "", errors.New(message) },
+		"required": func(message string, value interface{}) (interface{}, error) {
+			if value == nil {
+				return nil, errors.New(message)
+			}
+			return value, nil
+		},
+
+		// lists and dicts
+		"list":      func(values ...interface{}) []interface{} { return values },
+		"dict":      dict,
+		"keys":      keys,
+		"sortAlpha": sortAlpha,
+
+		// numbers
+		"add": func(a int, b int) int { return a + b },
+		"sub": func(a int, b int) int { return a - b },
+		"mul": func(a int, b int) int { return a * b },
+		"div": func(a int, b int) int { return a / b },
+
+		// encoding
+		"toJson":       toJSON,
+		"toPrettyJson": toPrettyJSON,
+		"fromJson":     fromJSON,
+		"toYaml":       toYAML,
+
+		// time
+		"now":  time.Now,
+		"date": func(layout string, t time.Time) string { return t.Format(layout) },
+	}
+}
+
+// title upper cases the first letter of each word
+func title(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		isStart := unicode.IsSpace(prev)
+		prev = r
+		if isStart {
+			return unicode.ToUpper(r)
+		}
+		return r
+	}, s)
+}
+
+func join(sep string, list interface{}) string {
+	var parts []string
+	for _, v := range toList(list) {
+		parts = append(parts, fmt.Sprint(v))
+	}
+	return strings.Join(parts, sep)
+}
+
+func trunc(length int, s string) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	return string(runes[:length])
+}
+
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func defaultValue(def interface{}, value ...interface{}) interface{} {
+	if len(value) == 0 || empty(value[0]) {
+		return def
+	}
+	return value[0]
+}
+
+// empty is true for nil and zero values, including empty lists and maps
+func empty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+func coalesce(values ...interface{}) interface{} {
+	for _, v := range values {
+		if !empty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func dict(kvs ...interface{}) (map[string]interface{}, error) {
+	if len(kvs)%2 != 0 {
+		return nil, errors.New("dict needs an even number of arguments")
+	}
+	res := make(map[string]interface{})
+	for i := 0; i < len(kvs); i += 2 {
+		res[fmt.Sprint(kvs[i])] = kvs[i+1]
+	}
+	return res, nil
+}
+
+func keys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return nil
+	}
+	var res []string
+	for _, k := range v.MapKeys() {
+		res = append(res, fmt.Sprint(k.Interface()))
+	}
+	sort.Strings(res)
+	return res
+}
+
+func sortAlpha(list interface{}) []string {
+	var res []string
+	for _, v := range toList(list) {
+		res = append(res, fmt.Sprint(v))
+	}
+	sort.Strings(res)
+	return res
+}
+
+func toList(list interface{}) []interface{} {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return []interface{}{list}
+	}
+	var res []interface{}
+	for i := 0; i < v.Len(); i++ {
+		res = append(res, v.Index(i).Interface())
+	}
+	return res
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func toPrettyJSON(v interface{}) (string, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	return string(b), err
+}
+
+func fromJSON(s string) (interface{}, error) {
+	var v interface{}
+	err := json.Unmarshal([]byte(s), &v)
+	return v, err
+}
+
+func toYAML(v interface{}) (string, error) {
+	b, err := yaml.Marshal(v)
+	return strings.TrimSuffix(string(b), "\n"), err
+}
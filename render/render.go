@@ -0,0 +1,69 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/alexgartner-bc/ci-multitool/cicontext"
+	"gopkg.in/yaml.v3"
+)
+
+// Data is what templates are executed with: {{ .Data.foo }}, {{ .Env.HOME }}, {{ .CI.SHA }}
+type Data struct {
+	// Data is decoded from a json or yaml file (nil if none)
+	Data interface{}
+	// Env only has the variables the template was given, the output is posted publicly and the environment has tokens
+	Env map[string]string
+	CI  *cicontext.Context
+}
+
+// NewData returns template data with the ci context and the environment variables in envNames (unset ones are "")
+func NewData(data interface{}, ci *cicontext.Context, envNames []string) *Data {
+	env := make(map[string]string)
+	for _, name := range envNames {
+		env[name] = os.Getenv(name)
+	}
+	return &Data{
+		Data: data,
+		Env:  env,
+		CI:   ci,
+	}
+}
+
+// ParseData decodes json or yaml (json is yaml)
+func ParseData(raw []byte) (interface{}, error) {
+	var data interface{}
+	err := yaml.Unmarshal(raw, &data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode data: %w", err)
+	}
+	return data, nil
+}
+
+// RenderFile executes the text/template in filename with data
+func RenderFile(filename string, data *Data) (string, error) {
+	text, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return Render(filename, string(text), data)
+}
+
+// Render executes a text/template with data. Missing map keys are nil so `default` can fill them in,
+// use `required` (or `fail`) for keys which must be set.
+func Render(name string, text string, data *Data) (string, error) {
+	tmpl, err := template.New(name).
+		Funcs(FuncMap()).
+		Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse template: %w", err)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("unable to render template: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,71 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/alexgartner-bc/ci-multitool/cicontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	t.Setenv("DEPLOY_ENV", "staging")
+	data, err := ParseData([]byte(`
+service: billing api
+replicas: 3
+regions: [us-east1, europe-west1]
+owner: ""
+`))
+	require.NoError(t, err)
+
+	out, err := Render("deploy", `### {{ .Data.service | title }} deployed to {{ .Env.DEPLOY_ENV | upper }}
+{{ .CI.SHA | trunc 7 }} {{ .Data.replicas }} replicas in {{ .Data.regions | sortAlpha | join ", " }}
+owner: {{ .Data.owner | default "nobody" }}
+{{- range $k := keys .Data }}
+- {{ $k }}
+{{- end }}
+`, NewData(data, &cicontext.Context{SHA: "0123456789abcdef"}, []string{"DEPLOY_ENV"}))
+	require.NoError(t, err)
+	require.Equal(t, `### Billing Api deployed to STAGING
+0123456 3 replicas in europe-west1, us-east1
+owner: nobody
+- owner
+- regions
+- replicas
+- service
+`, out)
+}
+
+func TestRenderJSONData(t *testing.T) {
+	data, err := ParseData([]byte(`{"changes": {"create": 1, "delete": 0}}`))
+	require.NoError(t, err)
+	out, err := Render("json", `{{ ternary "destructive" "safe" (gt .Data.changes.delete 0) }} {{ toJson .Data.changes }}`, NewData(data, &cicontext.Context{}, nil))
+	require.NoError(t, err)
+	require.Equal(t, `safe {"create":1,"delete":0}`, out)
+}
+
+func TestRenderMissingKey(t *testing.T) {
+	data := NewData(map[string]interface{}{"owner": "core"}, &cicontext.Context{}, nil)
+	out, err := Render("default", `{{ .Data.name | default "none" | upper }} {{ .Data.owner | default "nobody" }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "NONE core", out)
+
+	_, err = Render("required", `{{ .Data.name | required "name is required" }}`, data)
+	require.ErrorContains(t, err, "name is required")
+
+	_, err = Render("fail", `{{ fail "no data" }}`, NewData(nil, &cicontext.Context{}, nil))
+	require.ErrorContains(t, err, "no data")
+}
+
+func TestRenderEnvAllowlist(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "secret")
+	t.Setenv("DEPLOY_ENV", "staging")
+	data := NewData(nil, &cicontext.Context{}, []string{"DEPLOY_ENV"})
+
+	out, err := Render("env", `{{ .Env.DEPLOY_ENV }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "staging", out)
+
+	out, err = Render("token", `{{ .Env.GITHUB_TOKEN }}`, data)
+	require.NoError(t, err)
+	require.NotContains(t, out, "secret")
+}
@@ -0,0 +1,209 @@
+package sarif
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Merge combines logs into one. Runs of the same tool are merged into a single run and duplicate results are removed.
+// Results and rules are kept whole, the other fields of a merged run (invocations, properties...) are taken from
+// the first run of the tool. Rule indexes are rewritten against the merged rules, artifact indexes of the other runs
+// are dropped as their artifacts aren't kept.
+func Merge(logs ...*Log) *Log {
+	res := &Log{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+	}
+	runsByTool := make(map[string]*Run)
+	for _, log := range logs {
+		for _, run := range log.Runs {
+			merged, ok := runsByTool[run.Tool.Driver.Name]
+			first := !ok
+			if !ok {
+				driver := run.Tool.Driver
+				driver.Rules = nil
+				merged = &Run{
+					Tool: Tool{
+						Driver: driver,
+						Extra:  run.Tool.Extra,
+					},
+					Extra: run.Extra,
+				}
+				runsByTool[run.Tool.Driver.Name] = merged
+				res.Runs = append(res.Runs, merged)
+			}
+			merged.addRules(run.Tool.Driver.Rules)
+			for _, result := range run.Results {
+				merged.Results = append(merged.Results, merged.mergeResult(run, result, first))
+			}
+		}
+	}
+	for _, run := range res.Runs {
+		run.dedupe()
+	}
+	return res
+}
+
+func (r *Run) addRules(rules []*Rule) {
+	for _, rule := range rules {
+		if r.Rule(rule.ID) == nil {
+			r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, rule)
+		}
+	}
+}
+
+// mergeResult returns a copy of result (from run) which refers to the rules of r. The default level of its rule is
+// copied to the result as r may have another rule with the same id.
+func (r *Run) mergeResult(run *Run, result *Result, keepArtifacts bool) *Result {
+	res := *result
+	if rule := run.ResultRule(result); rule != nil {
+		res.RuleID = rule.ID
+		if res.Level == "" && rule.DefaultConfiguration != nil {
+			res.Level = rule.DefaultConfiguration.Level
+		}
+	}
+	if res.RuleIndex != nil {
+		res.RuleIndex = nil
+		for i, rule := range r.Tool.Driver.Rules {
+			if rule.ID == res.RuleID {
+				index := i
+				res.RuleIndex = &index
+				break
+			}
+		}
+	}
+	if !keepArtifacts {
+		res.Locations = nil
+		for _, loc := range result.Locations {
+			l := *loc
+			l.PhysicalLocation.ArtifactLocation.Index = nil
+			res.Locations = append(res.Locations, &l)
+		}
+	}
+	return &res
+}
+
+// dedupe removes results with the same rule, level, location and message
+func (r *Run) dedupe() {
+	seen := make(map[string]bool)
+	var results []*Result
+	for _, result := range r.Results {
+		key := r.fingerprint(result)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		results = append(results, result)
+	}
+	r.Results = results
+}
+
+func (r *Run) fingerprint(result *Result) string {
+	file, line := result.Location()
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%d\x00%s", result.RuleID, r.Level(result), file, line, result.Message.Text)
+}
+
+// Rule returns the rule with id (or nil)
+func (r *Run) Rule(id string) *Rule {
+	for _, rule := range r.Tool.Driver.Rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
+
+// levels in order of severity
+var levels = []string{"error", "warning", "note", "none"}
+
+// IsLevel is true for the levels a result can have
+func IsLevel(level string) bool {
+	return indexOf(levels, level) >= 0
+}
+
+// LevelAtLeast is true if level is as or more severe than min
+func LevelAtLeast(level string, min string) bool {
+	levelIndex := indexOf(levels, level)
+	minIndex := indexOf(levels, min)
+	return levelIndex >= 0 && minIndex >= 0 && levelIndex <= minIndex
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// Counts returns the number of results by level
+func (l *Log) Counts() map[string]int {
+	res := make(map[string]int)
+	for _, run := range l.Runs {
+		for _, result := range run.Results {
+			res[run.Level(result)]++
+		}
+	}
+	return res
+}
+
+// ShortSummaryString returns a short one line summary of the results
+func (l *Log) ShortSummaryString() string {
+	counts := l.Counts()
+	var parts []string
+	for _, level := range levels {
+		if counts[level] != 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", level, counts[level]))
+		}
+	}
+	if len(parts) == 0 {
+		return "no results"
+	}
+	return strings.Join(parts, " | ")
+}
+
+var levelIcons = map[string]string{
+	"error":   "❌",
+	"warning": "⚠️",
+	"note":    "ℹ️",
+	"none":    "ℹ️",
+}
+
+// Markdown returns the summary and a table of results for each tool, most severe first
+func (l *Log) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**sarif** (%s)\n", l.ShortSummaryString())
+	for _, run := range l.Runs {
+		if len(run.Results) == 0 {
+			continue
+		}
+		results := append([]*Result{}, run.Results...)
+		sort.SliceStable(results, func(i, j int) bool {
+			return indexOf(levels, run.Level(results[i])) < indexOf(levels, run.Level(results[j]))
+		})
+
+		fmt.Fprintf(&sb, "\n#### %s (%d)\n\n| | rule | location | message |\n|---|---|---|---|\n", run.Tool.Driver.Name, len(results))
+		for _, result := range results {
+			rule := result.RuleID
+			if r := run.ResultRule(result); r != nil {
+				rule = r.ID
+				if r.HelpURI != "" {
+					rule = fmt.Sprintf("[%s](%s)", rule, r.HelpURI)
+				}
+			}
+			location := ""
+			if file, line := result.Location(); file != "" {
+				location = fmt.Sprintf("`%s:%d`", file, line)
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", levelIcons[run.Level(result)], rule, location, markdownTableCell(result.Message.Text))
+		}
+	}
+	return sb.String()
+}
+
+func markdownTableCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,185 @@
+package sarif
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func result(ruleID string, level string, file string, line int, message string) *Result {
+	return &Result{
+		RuleID:  ruleID,
+		Level:   level,
+		Message: Message{Text: message},
+		Locations: []*Location{{
+			PhysicalLocation: PhysicalLocation{
+				ArtifactLocation: ArtifactLocation{URI: file},
+				Region:           &Region{StartLine: line},
+			},
+		}},
+	}
+}
+
+func TestMerge(t *testing.T) {
+	first := &Log{Version: "2.1.0", Runs: []*Run{{
+		Tool: Tool{Driver: Driver{Name: "gosec", Rules: []*Rule{{ID: "G104", HelpURI: "https://example.com/G104"}}}},
+		Results: []*Result{
+			result("G104", "error", "main.go", 3, "Errors unhandled."),
+			result("G104", "error", "main.go", 3, "Errors unhandled."),
+		},
+	}}}
+	second := &Log{Version: "2.1.0", Runs: []*Run{
+		{
+			Tool:    Tool{Driver: Driver{Name: "gosec", Rules: []*Rule{{ID: "G104"}, {ID: "G304"}}}},
+			Results: []*Result{result("G104", "error", "file://main.go", 3, "Errors unhandled.")},
+		},
+		{
+			Tool:    Tool{Driver: Driver{Name: "trivy"}},
+			Results: []*Result{result("CVE-1", "", "go.mod", 1, "vulnerable | dependency\nupgrade it")},
+		},
+	}}
+
+	merged := Merge(first, second)
+	require.Len(t, merged.Runs, 2)
+	require.Len(t, merged.Runs[0].Results, 1)
+	require.Len(t, merged.Runs[0].Tool.Driver.Rules, 2)
+	require.Equal(t, "error 1 | warning 1", merged.ShortSummaryString())
+	require.Equal(t, `**sarif** (error 1 | warning 1)
+
+#### gosec (1)
+
+| | rule | location | message |
+|---|---|---|---|
+| ❌ | [G104](https://example.com/G104) | `+"`main.go:3`"+` | Errors unhandled. |
+
+#### trivy (1)
+
+| | rule | location | message |
+|---|---|---|---|
+| ⚠️ | CVE-1 | `+"`go.mod:1`"+` | vulnerable \| dependency upgrade it |
+`, merged.Markdown())
+
+	require.Equal(t, "no results", Merge().ShortSummaryString())
+}
+
+func TestMergeRuleIndexes(t *testing.T) {
+	first, err := Parse([]byte(`{"version": "2.1.0", "runs": [{
+		"tool": {"driver": {"name": "gosec", "rules": [
+			{"id": "G104"},
+			{"id": "G304", "defaultConfiguration": {"level": "error"}}
+		]}},
+		"artifacts": [{"location": {"uri": "main.go"}}],
+		"results": [{"ruleIndex": 1, "message": {"text": "file inclusion"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "main.go", "index": 0}}}]}]
+	}]}`))
+	require.NoError(t, err)
+	second, err := Parse([]byte(`{"version": "2.1.0", "runs": [{
+		"tool": {"driver": {"name": "gosec", "rules": [
+			{"id": "G401", "defaultConfiguration": {"level": "note"}},
+			{"id": "G104"}
+		]}},
+		"artifacts": [{"location": {"uri": "crypto.go"}}],
+		"results": [
+			{"ruleId": "G104", "ruleIndex": 1, "message": {"text": "Errors unhandled."}},
+			{"ruleIndex": 0, "message": {"text": "weak crypto"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "crypto.go", "index": 0}}}]}
+		]
+	}]}`))
+	require.NoError(t, err)
+	require.Equal(t, "error 1", first.ShortSummaryString())
+	require.Equal(t, "warning 1 | note 1", second.ShortSummaryString())
+
+	merged := Merge(first, second)
+	run := merged.Runs[0]
+	var rules []string
+	for _, rule := range run.Tool.Driver.Rules {
+		rules = append(rules, rule.ID)
+	}
+	require.Equal(t, []string{"G104", "G304", "G401"}, rules)
+	require.Len(t, run.Results, 3)
+	for i, want := range []struct {
+		ruleID    string
+		ruleIndex int
+		level     string
+	}{
+		{ruleID: "G304", ruleIndex: 1, level: "error"},
+		{ruleID: "G104", ruleIndex: 0, level: "warning"},
+		{ruleID: "G401", ruleIndex: 2, level: "note"},
+	} {
+		require.Equal(t, want.ruleID, run.Results[i].RuleID)
+		require.Equal(t, want.ruleIndex, *run.Results[i].RuleIndex)
+		require.Equal(t, want.level, run.Level(run.Results[i]))
+	}
+	require.Equal(t, "error 1 | warning 1 | note 1", merged.ShortSummaryString())
+
+	// the artifacts are the first run's, only its indexes are kept
+	require.Equal(t, 0, *run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.Index)
+	require.Nil(t, run.Results[2].Locations[0].PhysicalLocation.ArtifactLocation.Index)
+	// the inputs aren't modified
+	require.NotNil(t, second.Runs[0].Results[1].Locations[0].PhysicalLocation.ArtifactLocation.Index)
+	require.Equal(t, 1, *second.Runs[0].Results[0].RuleIndex)
+}
+
+func TestLevelAtLeast(t *testing.T) {
+	require.True(t, LevelAtLeast("error", "warning"))
+	require.True(t, LevelAtLeast("warning", "warning"))
+	require.False(t, LevelAtLeast("note", "warning"))
+	require.False(t, LevelAtLeast("error", "never"))
+}
+
+func TestMergeKeepsUnmodelledFields(t *testing.T) {
+	log, err := Parse([]byte(`{
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {"driver": {"name": "gosec", "version": "2.18.2", "rules": [
+      {"id": "G104", "fullDescription": {"text": "Audit errors not checked"}, "properties": {"tags": ["security"]}}
+    ]}},
+    "invocations": [{"executionSuccessful": true}],
+    "properties": {"run": 1},
+    "results": [{
+      "ruleId": "G104",
+      "message": {"text": "Errors unhandled."},
+      "locations": [{"physicalLocation": {"artifactLocation": {"uri": "main.go", "index": 0}, "region": {"startLine": 3, "snippet": {"text": "f()"}}}}],
+      "partialFingerprints": {"primaryLocationLineHash": "abc:1"},
+      "properties": {"cwe": "703"}
+    }]
+  }]
+}`))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(Merge(log, log))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "version": "2.1.0",
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "runs": [{
+    "tool": {"driver": {"name": "gosec", "version": "2.18.2", "rules": [
+      {"id": "G104", "fullDescription": {"text": "Audit errors not checked"}, "properties": {"tags": ["security"]}}
+    ]}},
+    "invocations": [{"executionSuccessful": true}],
+    "properties": {"run": 1},
+    "results": [{
+      "ruleId": "G104",
+      "message": {"text": "Errors unhandled."},
+      "locations": [{"physicalLocation": {"artifactLocation": {"uri": "main.go", "index": 0}, "region": {"startLine": 3, "snippet": {"text": "f()"}}}}],
+      "partialFingerprints": {"primaryLocationLineHash": "abc:1"},
+      "properties": {"cwe": "703"}
+    }]
+  }]
+}`, string(data))
+}
+
+func TestMarshalOmitsEmptyObjects(t *testing.T) {
+	r := &Result{
+		RuleID:    "G104",
+		Message:   Message{Text: "Errors unhandled."},
+		Locations: []*Location{{PhysicalLocation: PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: "main.go"}}}},
+	}
+	data, err := json.Marshal(&Run{Tool: Tool{Driver: Driver{Name: "gosec", Rules: []*Rule{{ID: "G104"}}}}, Results: []*Result{r}})
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "region")
+	require.NotContains(t, string(data), "shortDescription")
+
+	file, line := r.Location()
+	require.Equal(t, "main.go", file)
+	require.Equal(t, 0, line)
+}
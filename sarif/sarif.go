@@ -0,0 +1,342 @@
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Log is the subset of a SARIF 2.1 log (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html) we use.
+// Below the log, the fields we don't model are kept in Extra and written back as is, so fingerprints, properties,
+// invocations and rule metadata survive a Merge.
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema,omitempty"`
+	Runs    []*Run `json:"runs"`
+}
+
+// Run is the output of a single tool
+type Run struct {
+	Tool    Tool      `json:"tool"`
+	Results []*Result `json:"results"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Tool describes the tool which produced a run
+type Tool struct {
+	Driver Driver `json:"driver"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Driver is the main component of a tool
+type Driver struct {
+	Name           string  `json:"name"`
+	InformationURI string  `json:"informationUri,omitempty"`
+	Rules          []*Rule `json:"rules,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Rule is a check a tool can report on
+type Rule struct {
+	ID                   string                  `json:"id"`
+	ShortDescription     *Message                `json:"shortDescription,omitempty"`
+	HelpURI              string                  `json:"helpUri,omitempty"`
+	DefaultConfiguration *ReportingConfiguration `json:"defaultConfiguration,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// ReportingConfiguration is the level results of a rule have when they don't set one
+type ReportingConfiguration struct {
+	Level string `json:"level,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Message is the text of a result or description
+type Message struct {
+	Text string `json:"text,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Result is a single finding
+type Result struct {
+	RuleID string `json:"ruleId,omitempty"`
+	// RuleIndex is the index of the rule in the driver's rules
+	RuleIndex *int        `json:"ruleIndex,omitempty"`
+	Level     string      `json:"level,omitempty"`
+	Message   Message     `json:"message"`
+	Locations []*Location `json:"locations,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Location is where a result was found
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// PhysicalLocation is a region of a file
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// ArtifactLocation is the file of a location
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+	// Index is the index of the file in the run's artifacts
+	Index *int `json:"index,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Region is the lines (and columns) of a location
+type Region struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Load reads a SARIF log from filename
+func Load(filename string) (*Log, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a SARIF log
+func Parse(data []byte) (*Log, error) {
+	log := &Log{}
+	err := json.Unmarshal(data, log)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode sarif: %w", err)
+	}
+	if !strings.HasPrefix(log.Version, "2.") {
+		return nil, fmt.Errorf("unsupported sarif version %q", log.Version)
+	}
+	return log, nil
+}
+
+// IsSARIF is true if data looks like a SARIF log
+func IsSARIF(data []byte) bool {
+	probe := struct {
+		Version string          `json:"version"`
+		Runs    json.RawMessage `json:"runs"`
+	}{}
+	err := json.Unmarshal(data, &probe)
+	return err == nil && probe.Version != "" && probe.Runs != nil
+}
+
+// Level returns the level of a result of the run, which defaults to the level configured for its rule, then warning
+func (r *Run) Level(result *Result) string {
+	if result.Level != "" {
+		return result.Level
+	}
+	if rule := r.ResultRule(result); rule != nil && rule.DefaultConfiguration != nil && rule.DefaultConfiguration.Level != "" {
+		return rule.DefaultConfiguration.Level
+	}
+	return "warning"
+}
+
+// ResultRule returns the rule of a result of the run, by id or index (or nil)
+func (r *Run) ResultRule(result *Result) *Rule {
+	if result.RuleID != "" {
+		return r.Rule(result.RuleID)
+	}
+	if result.RuleIndex != nil && *result.RuleIndex >= 0 && *result.RuleIndex < len(r.Tool.Driver.Rules) {
+		return r.Tool.Driver.Rules[*result.RuleIndex]
+	}
+	return nil
+}
+
+// Location returns the file (relative to the repo when possible) and line of the first location of the result
+func (r *Result) Location() (string, int) {
+	if len(r.Locations) == 0 {
+		return "", 0
+	}
+	loc := r.Locations[0].PhysicalLocation
+	uri := strings.TrimPrefix(loc.ArtifactLocation.URI, "file://")
+	uri = strings.TrimPrefix(uri, "./")
+	if loc.Region == nil {
+		return uri, 0
+	}
+	return uri, loc.Region.StartLine
+}
+
+// unmarshalWithExtra decodes data into v (a pointer to a struct) and the fields v doesn't have into extra
+func unmarshalWithExtra(data []byte, v interface{}, extra *map[string]json.RawMessage) error {
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		return err
+	}
+	all := make(map[string]json.RawMessage)
+	err = json.Unmarshal(data, &all)
+	if err != nil {
+		return err
+	}
+	for _, name := range jsonFieldNames(reflect.TypeOf(v).Elem()) {
+		delete(all, name)
+	}
+	*extra = nil
+	if len(all) > 0 {
+		*extra = all
+	}
+	return nil
+}
+
+// marshalWithExtra encodes v (a struct) with the fields in extra added
+func marshalWithExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	all := make(map[string]json.RawMessage)
+	err = json.Unmarshal(data, &all)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range extra {
+		if _, ok := all[name]; !ok {
+			all[name] = value
+		}
+	}
+	return json.Marshal(all)
+}
+
+// jsonFieldNames returns the json names of the fields of a struct type
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (v *Run) UnmarshalJSON(data []byte) error {
+	type plain Run
+	return unmarshalWithExtra(data, (*plain)(v), &v.Extra)
+}
+
+func (v Run) MarshalJSON() ([]byte, error) {
+	type plain Run
+	return marshalWithExtra(plain(v), v.Extra)
+}
+
+func (v *Tool) UnmarshalJSON(data []byte) error {
+	type plain Tool
+	return unmarshalWithExtra(data, (*plain)(v), &v.Extra)
+}
+
+func (v Tool) MarshalJSON() ([]byte, error) {
+	type plain Tool
+	return marshalWithExtra(plain(v), v.Extra)
+}
+
+func (v *Driver) UnmarshalJSON(data []byte) error {
+	type plain Driver
+	return unmarshalWithExtra(data, (*plain)(v), &v.Extra)
+}
+
+func (v Driver) MarshalJSON() ([]byte, error) {
+	type plain Driver
+	return marshalWithExtra(plain(v), v.Extra)
+}
+
+func (v *Rule) UnmarshalJSON(data []byte) error {
+	type plain Rule
+	return unmarshalWithExtra(data, (*plain)(v), &v.Extra)
+}
+
+func (v Rule) MarshalJSON() ([]byte, error) {
+	type plain Rule
+	return marshalWithExtra(plain(v), v.Extra)
+}
+
+func (v *ReportingConfiguration) UnmarshalJSON(data []byte) error {
+	type plain ReportingConfiguration
+	return unmarshalWithExtra(data, (*plain)(v), &v.Extra)
+}
+
+func (v ReportingConfiguration) MarshalJSON() ([]byte, error) {
+	type plain ReportingConfiguration
+	return marshalWithExtra(plain(v), v.Extra)
+}
+
+func (v *Message) UnmarshalJSON(data []byte) error {
+	type plain Message
+	return unmarshalWithExtra(data, (*plain)(v), &v.Extra)
+}
+
+func (v Message) MarshalJSON() ([]byte, error) {
+	type plain Message
+	return marshalWithExtra(plain(v), v.Extra)
+}
+
+func (v *Result) UnmarshalJSON(data []byte) error {
+	type plain Result
+	return unmarshalWithExtra(data, (*plain)(v), &v.Extra)
+}
+
+func (v Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	return marshalWithExtra(plain(v), v.Extra)
+}
+
+func (v *Location) UnmarshalJSON(data []byte) error {
+	type plain Location
+	return unmarshalWithExtra(data, (*plain)(v), &v.Extra)
+}
+
+func (v Location) MarshalJSON() ([]byte, error) {
+	type plain Location
+	return marshalWithExtra(plain(v), v.Extra)
+}
+
+func (v *PhysicalLocation) UnmarshalJSON(data []byte) error {
+	type plain PhysicalLocation
+	return unmarshalWithExtra(data, (*plain)(v), &v.Extra)
+}
+
+func (v PhysicalLocation) MarshalJSON() ([]byte, error) {
+	type plain PhysicalLocation
+	return marshalWithExtra(plain(v), v.Extra)
+}
+
+func (v *ArtifactLocation) UnmarshalJSON(data []byte) error {
+	type plain ArtifactLocation
+	return unmarshalWithExtra(data, (*plain)(v), &v.Extra)
+}
+
+func (v ArtifactLocation) MarshalJSON() ([]byte, error) {
+	type plain ArtifactLocation
+	return marshalWithExtra(plain(v), v.Extra)
+}
+
+func (v *Region) UnmarshalJSON(data []byte) error {
+	type plain Region
+	return unmarshalWithExtra(data, (*plain)(v), &v.Extra)
+}
+
+func (v Region) MarshalJSON() ([]byte, error) {
+	type plain Region
+	return marshalWithExtra(plain(v), v.Extra)
+}
@@ -0,0 +1,172 @@
+package scm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/alexgartner-bc/ci-multitool/github"
+)
+
+// Gitea is the Backend for gitea (and forgejo) pull requests
+type Gitea struct {
+	client *restClient
+}
+
+// NewGitea configures gitea from the environment:
+// GITEA_API_URL (default GITHUB_SERVER_URL/api/v1 as set by gitea actions) and GITEA_TOKEN (default GITHUB_TOKEN)
+func NewGitea() (*Gitea, error) {
+	baseURL := os.Getenv("GITEA_API_URL")
+	if baseURL == "" && os.Getenv("GITHUB_SERVER_URL") != "" {
+		baseURL = strings.TrimSuffix(os.Getenv("GITHUB_SERVER_URL"), "/") + "/api/v1"
+	}
+	if baseURL == "" {
+		return nil, errors.New("GITEA_API_URL must be set")
+	}
+	header := http.Header{}
+	token := os.Getenv("GITEA_TOKEN")
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token != "" {
+		header.Set("Authorization", "token "+token)
+	}
+	return &Gitea{
+		client: newRESTClient(baseURL, header),
+	}, nil
+}
+
+type giteaComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+func giteaRepoPath(repo string) (string, error) {
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("repo must be owner/repo, got %q", repo)
+	}
+	return fmt.Sprintf("/repos/%s/%s", url.PathEscape(parts[0]), url.PathEscape(parts[1])), nil
+}
+
+// listPullRequestComments returns the comments on a pull request tagged with stickyKey, newest first.
+// The issue comments endpoint isn't paginated, it returns every comment at once.
+func (g *Gitea) listPullRequestComments(ctx context.Context, repo string, number int, stickyKey string) ([]*giteaComment, error) {
+	repoPath, err := giteaRepoPath(repo)
+	if err != nil {
+		return nil, err
+	}
+	var comments []*giteaComment
+	_, err = g.client.do(ctx, http.MethodGet, fmt.Sprintf("%s/issues/%d/comments", repoPath, number), nil, nil, &comments)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list pull request comments: %w", err)
+	}
+	var res []*giteaComment
+	for _, comment := range comments {
+		if strings.Contains(comment.Body, github.StickyKeyText(stickyKey)) {
+			// oldest first
+			res = append([]*giteaComment{comment}, res...)
+		}
+	}
+	return res, nil
+}
+
+func (g *Gitea) CommentOnChangeRequest(ctx context.Context, repo string, number int, text string, stickyKey string) error {
+	repoPath, err := giteaRepoPath(repo)
+	if err != nil {
+		return err
+	}
+	comments, err := g.listPullRequestComments(ctx, repo, number, stickyKey)
+	if err != nil {
+		return err
+	}
+	req := map[string]string{"body": text + github.StickyKeyText(stickyKey)}
+
+	if len(comments) == 0 {
+		_, err = g.client.do(ctx, http.MethodPost, fmt.Sprintf("%s/issues/%d/comments", repoPath, number), nil, req, nil)
+		if err != nil {
+			return fmt.Errorf("unable to create pull request comment: %w", err)
+		}
+		return nil
+	}
+
+	// update the newest comment and clean up any duplicates
+	_, err = g.client.do(ctx, http.MethodPatch, fmt.Sprintf("%s/issues/comments/%d", repoPath, comments[0].ID), nil, req, nil)
+	if err != nil {
+		return fmt.Errorf("unable to edit pull request comment: %w", err)
+	}
+	for _, comment := range comments[1:] {
+		_, err = g.client.do(ctx, http.MethodDelete, fmt.Sprintf("%s/issues/comments/%d", repoPath, comment.ID), nil, nil, nil)
+		if err != nil {
+			return fmt.Errorf("unable to delete duplicate pull request comment: %w", err)
+		}
+	}
+	return nil
+}
+
+func (g *Gitea) DeleteChangeRequestComment(ctx context.Context, repo string, number int, stickyKey string) error {
+	repoPath, err := giteaRepoPath(repo)
+	if err != nil {
+		return err
+	}
+	comments, err := g.listPullRequestComments(ctx, repo, number, stickyKey)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		_, err = g.client.do(ctx, http.MethodDelete, fmt.Sprintf("%s/issues/comments/%d", repoPath, comment.ID), nil, nil, nil)
+		if err != nil {
+			return fmt.Errorf("unable to delete pull request comment: %w", err)
+		}
+	}
+	return nil
+}
+
+// CommentOnCommit isn't supported, gitea has no api for commit comments
+func (g *Gitea) CommentOnCommit(ctx context.Context, repo string, sha string, text string, stickyKey string) error {
+	return errors.New("gitea doesn't support commit comments, use --pr")
+}
+
+func (g *Gitea) SetDescriptionTrailer(ctx context.Context, repo string, number int, summary string, details string, stickyKey string) error {
+	repoPath, err := giteaRepoPath(repo)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("%s/pulls/%d", repoPath, number)
+	var block string
+	err = github.EditDescription(ctx,
+		func() (string, error) {
+			pr := struct {
+				Body string `json:"body"`
+			}{}
+			_, err := g.client.do(ctx, http.MethodGet, path, nil, nil, &pr)
+			if err != nil {
+				return "", fmt.Errorf("unable to get pull request: %w", err)
+			}
+			return strings.ReplaceAll(pr.Body, "\r\n", "\n"), nil
+		},
+		func(body string) error {
+			_, err := g.client.do(ctx, http.MethodPatch, path, nil, map[string]string{"body": body}, nil)
+			if err != nil {
+				return fmt.Errorf("unable to edit pull request: %w", err)
+			}
+			return nil
+		},
+		func(body string) (string, error) {
+			var newBody string
+			newBody, block = github.MergeTrailer(body, summary, details, stickyKey)
+			return newBody, nil
+		},
+		func(body string) bool {
+			return strings.Contains(body, block)
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to set pull request trailer %s: %w", stickyKey, err)
+	}
+	return nil
+}
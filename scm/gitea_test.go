@@ -0,0 +1,140 @@
+package scm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexgartner-bc/ci-multitool/github"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitea is a minimal in-memory gitea api for pull request 1 of owner/repo
+type fakeGitea struct {
+	mu       sync.Mutex
+	comments map[int64]string
+	nextID   int64
+	body     string
+	lists    int
+}
+
+func newFakeGitea(t *testing.T) (*fakeGitea, *Gitea) {
+	f := &fakeGitea{comments: make(map[int64]string), nextID: 1}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	t.Setenv("GITEA_API_URL", srv.URL+"/api/v1")
+	t.Setenv("GITEA_TOKEN", "fake")
+
+	oldDelay := github.TrailerSettleDelay
+	github.TrailerSettleDelay = time.Millisecond
+	t.Cleanup(func() {
+		github.TrailerSettleDelay = oldDelay
+	})
+	g, err := NewGitea()
+	require.NoError(t, err)
+	return f, g
+}
+
+func (f *fakeGitea) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "token fake" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	const repo = "/api/v1/repos/owner/repo"
+	path := r.URL.Path
+	req := map[string]string{}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+	switch {
+	case path == repo+"/pulls/1" && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]string{"body": f.body})
+	case path == repo+"/pulls/1" && r.Method == http.MethodPatch:
+		f.body = req["body"]
+		fmt.Fprint(w, "{}")
+	case path == repo+"/issues/1/comments" && r.Method == http.MethodGet:
+		// like gitea, ignore page and limit and return every comment oldest first
+		f.lists++
+		var ids []int64
+		for id := range f.comments {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		comments := []map[string]interface{}{}
+		for _, id := range ids {
+			comments = append(comments, map[string]interface{}{"id": id, "body": f.comments[id]})
+		}
+		json.NewEncoder(w).Encode(comments)
+	case path == repo+"/issues/1/comments" && r.Method == http.MethodPost:
+		f.comments[f.nextID] = req["body"]
+		f.nextID++
+		fmt.Fprint(w, "{}")
+	case strings.HasPrefix(path, repo+"/issues/comments/"):
+		var id int64
+		fmt.Sscanf(strings.TrimPrefix(path, repo+"/issues/comments/"), "%d", &id)
+		if _, ok := f.comments[id]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.comments, id)
+		} else {
+			f.comments[id] = req["body"]
+		}
+		fmt.Fprint(w, "{}")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestGiteaCommentOnChangeRequest(t *testing.T) {
+	f, g := newFakeGitea(t)
+	ctx := context.Background()
+
+	// more comments than a page, with duplicates of the sticky comment
+	for i := 0; i < 60; i++ {
+		f.comments[f.nextID] = fmt.Sprintf("comment %d", i)
+		f.nextID++
+	}
+	f.comments[f.nextID] = "old" + github.StickyKeyText("preview")
+	f.nextID++
+	f.comments[f.nextID] = "older" + github.StickyKeyText("preview")
+	f.nextID++
+
+	require.NoError(t, g.CommentOnChangeRequest(ctx, "owner/repo", 1, "first", "preview"))
+	require.Equal(t, 1, f.lists)
+	require.Len(t, f.comments, 61)
+	require.Equal(t, "first"+github.StickyKeyText("preview"), f.comments[62])
+
+	require.NoError(t, g.CommentOnChangeRequest(ctx, "owner/repo", 1, "other", "lint"))
+	require.Len(t, f.comments, 62)
+
+	require.NoError(t, g.DeleteChangeRequestComment(ctx, "owner/repo", 1, "preview"))
+	require.Len(t, f.comments, 61)
+	require.NoError(t, g.DeleteChangeRequestComment(ctx, "owner/repo", 1, "preview"))
+}
+
+func TestGiteaCommentOnCommit(t *testing.T) {
+	_, g := newFakeGitea(t)
+	require.ErrorContains(t, g.CommentOnCommit(context.Background(), "owner/repo", "abc123", "text", "preview"), "doesn't support commit comments")
+}
+
+func TestGiteaSetDescriptionTrailer(t *testing.T) {
+	f, g := newFakeGitea(t)
+	ctx := context.Background()
+	f.body = "fixes the thing"
+
+	require.NoError(t, g.SetDescriptionTrailer(ctx, "owner/repo", 1, "preview", "1 to create", "pulumi"))
+	require.NoError(t, g.SetDescriptionTrailer(ctx, "owner/repo", 1, "preview", "2 to create", "pulumi"))
+	require.Equal(t, "fixes the thing\n<details id=\"pulumi\"><summary>preview</summary>\n\n2 to create\n\n</details>", f.body)
+}
@@ -0,0 +1,29 @@
+package scm
+
+import (
+	"context"
+
+	"github.com/alexgartner-bc/ci-multitool/github"
+)
+
+// Github is the Backend for github using the github package
+type Github struct {
+	// Options for change request comments (can be nil)
+	Options *github.CommentOptions
+}
+
+func (g *Github) CommentOnChangeRequest(ctx context.Context, repo string, number int, text string, stickyKey string) error {
+	return github.CommentOnIssueWithOptions(ctx, repo, number, text, stickyKey, g.Options)
+}
+
+func (g *Github) DeleteChangeRequestComment(ctx context.Context, repo string, number int, stickyKey string) error {
+	return github.DeleteIssueComment(ctx, repo, number, stickyKey)
+}
+
+func (g *Github) CommentOnCommit(ctx context.Context, repo string, sha string, text string, stickyKey string) error {
+	return github.CommentOnCommitWithOptions(ctx, repo, sha, text, stickyKey, g.Options)
+}
+
+func (g *Github) SetDescriptionTrailer(ctx context.Context, repo string, number int, summary string, details string, stickyKey string) error {
+	return github.SetPRTrailerDetails(ctx, repo, number, summary, details, stickyKey)
+}
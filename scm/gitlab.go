@@ -0,0 +1,216 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alexgartner-bc/ci-multitool/github"
+)
+
+// Gitlab is the Backend for gitlab merge requests (notes and description) and commits (discussions)
+type Gitlab struct {
+	client *restClient
+}
+
+// NewGitlab configures gitlab from the environment:
+// GITLAB_API_URL (default CI_API_V4_URL or https://gitlab.com/api/v4) and GITLAB_TOKEN (default CI_JOB_TOKEN)
+func NewGitlab() *Gitlab {
+	baseURL := os.Getenv("GITLAB_API_URL")
+	if baseURL == "" {
+		baseURL = os.Getenv("CI_API_V4_URL")
+	}
+	if baseURL == "" {
+		baseURL = "https://gitlab.com/api/v4"
+	}
+	header := http.Header{}
+	if token := os.Getenv("GITLAB_TOKEN"); token != "" {
+		header.Set("PRIVATE-TOKEN", token)
+	} else if token := os.Getenv("CI_JOB_TOKEN"); token != "" {
+		header.Set("JOB-TOKEN", token)
+	}
+	return &Gitlab{
+		client: newRESTClient(baseURL, header),
+	}
+}
+
+type gitlabNote struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	// System notes are changes made to the merge request (pushes, label changes...)
+	System bool `json:"system"`
+}
+
+type gitlabDiscussion struct {
+	ID    string        `json:"id"`
+	Notes []*gitlabNote `json:"notes"`
+}
+
+func gitlabProjectPath(repo string) string {
+	return "/projects/" + url.PathEscape(repo)
+}
+
+// listPages gets every page of path. decode returns what to decode a page into and a func to collect it once decoded
+func (g *Gitlab) listPages(ctx context.Context, path string, query url.Values, decode func() (interface{}, func())) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", "100")
+	for page := 1; ; {
+		query.Set("page", strconv.Itoa(page))
+		res, collect := decode()
+		resp, err := g.client.do(ctx, http.MethodGet, path, query, nil, res)
+		if err != nil {
+			return err
+		}
+		collect()
+		next, err := strconv.Atoi(resp.Header.Get("X-Next-Page"))
+		if err != nil || next == 0 {
+			return nil
+		}
+		page = next
+	}
+}
+
+// listMergeRequestNotes returns the notes on a merge request tagged with stickyKey, newest first
+func (g *Gitlab) listMergeRequestNotes(ctx context.Context, repo string, number int, stickyKey string) ([]*gitlabNote, error) {
+	path := fmt.Sprintf("%s/merge_requests/%d/notes", gitlabProjectPath(repo), number)
+	query := url.Values{
+		"sort":     []string{"desc"},
+		"order_by": []string{"created_at"},
+	}
+	var res []*gitlabNote
+	err := g.listPages(ctx, path, query, func() (interface{}, func()) {
+		var notes []*gitlabNote
+		return &notes, func() {
+			for _, note := range notes {
+				if !note.System && strings.Contains(note.Body, github.StickyKeyText(stickyKey)) {
+					res = append(res, note)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list merge request notes: %w", err)
+	}
+	return res, nil
+}
+
+func (g *Gitlab) CommentOnChangeRequest(ctx context.Context, repo string, number int, text string, stickyKey string) error {
+	notes, err := g.listMergeRequestNotes(ctx, repo, number, stickyKey)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("%s/merge_requests/%d/notes", gitlabProjectPath(repo), number)
+	req := map[string]string{"body": text + github.StickyKeyText(stickyKey)}
+
+	if len(notes) == 0 {
+		_, err = g.client.do(ctx, http.MethodPost, path, nil, req, nil)
+		if err != nil {
+			return fmt.Errorf("unable to create merge request note: %w", err)
+		}
+		return nil
+	}
+
+	// update the newest note and clean up any duplicates
+	_, err = g.client.do(ctx, http.MethodPut, fmt.Sprintf("%s/%d", path, notes[0].ID), nil, req, nil)
+	if err != nil {
+		return fmt.Errorf("unable to edit merge request note: %w", err)
+	}
+	for _, note := range notes[1:] {
+		_, err = g.client.do(ctx, http.MethodDelete, fmt.Sprintf("%s/%d", path, note.ID), nil, nil, nil)
+		if err != nil {
+			return fmt.Errorf("unable to delete duplicate merge request note: %w", err)
+		}
+	}
+	return nil
+}
+
+func (g *Gitlab) DeleteChangeRequestComment(ctx context.Context, repo string, number int, stickyKey string) error {
+	notes, err := g.listMergeRequestNotes(ctx, repo, number, stickyKey)
+	if err != nil {
+		return err
+	}
+	for _, note := range notes {
+		path := fmt.Sprintf("%s/merge_requests/%d/notes/%d", gitlabProjectPath(repo), number, note.ID)
+		_, err = g.client.do(ctx, http.MethodDelete, path, nil, nil, nil)
+		if err != nil {
+			return fmt.Errorf("unable to delete merge request note: %w", err)
+		}
+	}
+	return nil
+}
+
+// CommentOnCommit uses a discussion on the commit, plain commit comments can't be edited
+func (g *Gitlab) CommentOnCommit(ctx context.Context, repo string, sha string, text string, stickyKey string) error {
+	path := fmt.Sprintf("%s/repository/commits/%s/discussions", gitlabProjectPath(repo), url.PathEscape(sha))
+	var existing *gitlabDiscussion
+	err := g.listPages(ctx, path, nil, func() (interface{}, func()) {
+		var discussions []*gitlabDiscussion
+		return &discussions, func() {
+			for _, discussion := range discussions {
+				if len(discussion.Notes) > 0 && strings.Contains(discussion.Notes[0].Body, github.StickyKeyText(stickyKey)) {
+					// oldest first, keep the newest
+					existing = discussion
+				}
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("unable to list commit discussions: %w", err)
+	}
+
+	req := map[string]string{"body": text + github.StickyKeyText(stickyKey)}
+	if existing == nil {
+		_, err = g.client.do(ctx, http.MethodPost, path, nil, req, nil)
+		if err != nil {
+			return fmt.Errorf("unable to create commit discussion: %w", err)
+		}
+		return nil
+	}
+	_, err = g.client.do(ctx, http.MethodPut, fmt.Sprintf("%s/%s/notes/%d", path, existing.ID, existing.Notes[0].ID), nil, req, nil)
+	if err != nil {
+		return fmt.Errorf("unable to edit commit discussion: %w", err)
+	}
+	return nil
+}
+
+func (g *Gitlab) SetDescriptionTrailer(ctx context.Context, repo string, number int, summary string, details string, stickyKey string) error {
+	path := fmt.Sprintf("%s/merge_requests/%d", gitlabProjectPath(repo), number)
+	var block string
+	err := github.EditDescription(ctx,
+		func() (string, error) {
+			mr := struct {
+				Description string `json:"description"`
+			}{}
+			_, err := g.client.do(ctx, http.MethodGet, path, nil, nil, &mr)
+			if err != nil {
+				return "", fmt.Errorf("unable to get merge request: %w", err)
+			}
+			return strings.ReplaceAll(mr.Description, "\r\n", "\n"), nil
+		},
+		func(body string) error {
+			_, err := g.client.do(ctx, http.MethodPut, path, nil, map[string]string{"description": body}, nil)
+			if err != nil {
+				return fmt.Errorf("unable to edit merge request: %w", err)
+			}
+			return nil
+		},
+		func(body string) (string, error) {
+			var newBody string
+			newBody, block = github.MergeTrailer(body, summary, details, stickyKey)
+			return newBody, nil
+		},
+		func(body string) bool {
+			return strings.Contains(body, block)
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to set merge request trailer %s: %w", stickyKey, err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package scm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexgartner-bc/ci-multitool/github"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitlab is a minimal in-memory gitlab api for merge request 1 and commit abc123 of group/project
+type fakeGitlab struct {
+	mu    sync.Mutex
+	notes map[int64]string
+	// commitNotes are the first notes of the commit's discussions, discussion "d<id>" has note id
+	commitNotes map[int64]string
+	nextID      int64
+	description string
+}
+
+func newFakeGitlab(t *testing.T) (*fakeGitlab, *Gitlab) {
+	f := &fakeGitlab{notes: make(map[int64]string), commitNotes: make(map[int64]string), nextID: 1}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	t.Setenv("GITLAB_API_URL", srv.URL+"/api/v4")
+	t.Setenv("GITLAB_TOKEN", "fake")
+
+	oldDelay := github.TrailerSettleDelay
+	github.TrailerSettleDelay = time.Millisecond
+	t.Cleanup(func() {
+		github.TrailerSettleDelay = oldDelay
+	})
+	return f, NewGitlab()
+}
+
+func (f *fakeGitlab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("PRIVATE-TOKEN") != "fake" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// the project path is escaped
+	const mr = "/api/v4/projects/group%2Fproject/merge_requests/1"
+	const commit = "/api/v4/projects/group%2Fproject/repository/commits/abc123/discussions"
+	path := r.URL.EscapedPath()
+	req := map[string]string{}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+	switch {
+	case path == mr && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]string{"description": f.description})
+	case path == mr && r.Method == http.MethodPut:
+		f.description = req["description"]
+		fmt.Fprint(w, "{}")
+	case path == mr+"/notes" && r.Method == http.MethodGet:
+		var ids []int64
+		for id := range f.notes {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+		var notes []map[string]interface{}
+		for _, id := range ids {
+			notes = append(notes, map[string]interface{}{"id": id, "body": f.notes[id]})
+		}
+		// a system note
+		notes = append(notes, map[string]interface{}{"id": 0, "body": "added 1 commit", "system": true})
+		json.NewEncoder(w).Encode(notes)
+	case path == mr+"/notes" && r.Method == http.MethodPost:
+		f.notes[f.nextID] = req["body"]
+		f.nextID++
+		fmt.Fprint(w, "{}")
+	case strings.HasPrefix(path, mr+"/notes/"):
+		var id int64
+		fmt.Sscanf(strings.TrimPrefix(path, mr+"/notes/"), "%d", &id)
+		if _, ok := f.notes[id]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.notes, id)
+		} else {
+			f.notes[id] = req["body"]
+		}
+		fmt.Fprint(w, "{}")
+	case path == commit && r.Method == http.MethodGet:
+		var ids []int64
+		for id := range f.commitNotes {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		var discussions []map[string]interface{}
+		for _, id := range ids {
+			discussions = append(discussions, map[string]interface{}{
+				"id":    fmt.Sprintf("d%d", id),
+				"notes": []map[string]interface{}{{"id": id, "body": f.commitNotes[id]}},
+			})
+		}
+		json.NewEncoder(w).Encode(discussions)
+	case path == commit && r.Method == http.MethodPost:
+		f.commitNotes[f.nextID] = req["body"]
+		f.nextID++
+		fmt.Fprint(w, "{}")
+	case strings.HasPrefix(path, commit+"/") && r.Method == http.MethodPut:
+		var discussionID, id int64
+		fmt.Sscanf(strings.TrimPrefix(path, commit+"/"), "d%d/notes/%d", &discussionID, &id)
+		if _, ok := f.commitNotes[id]; !ok || discussionID != id {
+			http.NotFound(w, r)
+			return
+		}
+		f.commitNotes[id] = req["body"]
+		fmt.Fprint(w, "{}")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestGitlabCommentOnChangeRequest(t *testing.T) {
+	f, g := newFakeGitlab(t)
+	ctx := context.Background()
+
+	require.NoError(t, g.CommentOnChangeRequest(ctx, "group/project", 1, "first", "preview"))
+	require.NoError(t, g.CommentOnChangeRequest(ctx, "group/project", 1, "second", "preview"))
+	require.NoError(t, g.CommentOnChangeRequest(ctx, "group/project", 1, "other", "lint"))
+	require.Len(t, f.notes, 2)
+	require.Equal(t, "second\n<!-- key preview -->\n", f.notes[1])
+
+	require.NoError(t, g.DeleteChangeRequestComment(ctx, "group/project", 1, "preview"))
+	require.Len(t, f.notes, 1)
+	require.NoError(t, g.DeleteChangeRequestComment(ctx, "group/project", 1, "preview"))
+}
+
+func TestGitlabCommentOnCommit(t *testing.T) {
+	f, g := newFakeGitlab(t)
+	ctx := context.Background()
+
+	require.NoError(t, g.CommentOnCommit(ctx, "group/project", "abc123", "first", "preview"))
+	require.NoError(t, g.CommentOnCommit(ctx, "group/project", "abc123", "second", "preview"))
+	require.NoError(t, g.CommentOnCommit(ctx, "group/project", "abc123", "other", "lint"))
+	require.Len(t, f.commitNotes, 2)
+	require.Equal(t, "second"+github.StickyKeyText("preview"), f.commitNotes[1])
+	require.Equal(t, "other"+github.StickyKeyText("lint"), f.commitNotes[2])
+}
+
+func TestGitlabSetDescriptionTrailer(t *testing.T) {
+	f, g := newFakeGitlab(t)
+	ctx := context.Background()
+	f.description = "fixes the thing"
+
+	require.NoError(t, g.SetDescriptionTrailer(ctx, "group/project", 1, "preview", "1 to create", "pulumi"))
+	require.NoError(t, g.SetDescriptionTrailer(ctx, "group/project", 1, "preview", "2 to create", "pulumi"))
+	require.Equal(t, "fixes the thing\n<details id=\"pulumi\"><summary>preview</summary>\n\n2 to create\n\n</details>", f.description)
+}
@@ -0,0 +1,82 @@
+package scm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/alexgartner-bc/ci-multitool/dryrun"
+)
+
+// restClient is a minimal json api client for the backends without a go-github equivalent
+type restClient struct {
+	baseURL    string
+	header     http.Header
+	httpClient *http.Client
+}
+
+func newRESTClient(baseURL string, header http.Header) *restClient {
+	c := &restClient{
+		baseURL: baseURL,
+		header:  header,
+	}
+	if dryrun.Enabled {
+		c.httpClient = &http.Client{Transport: &dryrun.Transport{}}
+	}
+	return c
+}
+
+// do sends body as json (if not nil) and decodes the response into res (if not nil)
+func (c *restClient) do(ctx context.Context, method string, path string, query url.Values, body interface{}, res interface{}) (*http.Response, error) {
+	u := strings.TrimSuffix(c.baseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return resp, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if res != nil {
+		err = json.NewDecoder(resp.Body).Decode(res)
+		if err != nil {
+			return resp, fmt.Errorf("unable to decode %s %s: %w", method, path, err)
+		}
+	}
+	return resp, nil
+}
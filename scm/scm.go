@@ -0,0 +1,52 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexgartner-bc/ci-multitool/cicontext"
+)
+
+// supported backends
+const (
+	NameGithub = "github"
+	NameGitlab = "gitlab"
+	NameGitea  = "gitea"
+)
+
+// Backend posts reports to a source control host. A change request is a github/gitea pull request or a gitlab merge request.
+//
+// repo is owner/repo (group/subgroup/project on gitlab). Comments are sticky: the one tagged with stickyKey is updated.
+type Backend interface {
+	CommentOnChangeRequest(ctx context.Context, repo string, number int, text string, stickyKey string) error
+	// DeleteChangeRequestComment is not an error if there is no comment
+	DeleteChangeRequestComment(ctx context.Context, repo string, number int, stickyKey string) error
+	// CommentOnCommit posts a sticky comment on a commit, for pipelines without a change request (pushes to a branch)
+	CommentOnCommit(ctx context.Context, repo string, sha string, text string, stickyKey string) error
+	// SetDescriptionTrailer sets text at the bottom of the change request description (see github.SetPRTrailerDetails)
+	SetDescriptionTrailer(ctx context.Context, repo string, number int, summary string, details string, stickyKey string) error
+}
+
+// New returns the backend called name, configured from the environment
+func New(name string) (Backend, error) {
+	switch name {
+	case NameGithub:
+		return &Github{}, nil
+	case NameGitlab:
+		return NewGitlab(), nil
+	case NameGitea:
+		return NewGitea()
+	}
+	return nil, fmt.Errorf("unknown scm %q (github, gitlab, gitea)", name)
+}
+
+// Detect returns the name of the backend for the ci the tool is running in (github if unknown)
+func Detect(ci *cicontext.Context) string {
+	switch ci.Provider {
+	case cicontext.ProviderGitlab:
+		return NameGitlab
+	case cicontext.ProviderGitea:
+		return NameGitea
+	}
+	return NameGithub
+}